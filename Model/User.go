@@ -2,21 +2,62 @@ package Model
 
 import "time"
 
+// 用户角色，权限从高到低；每个角色拥有的权限定义在 rbac 包中
+const (
+	RoleAdmin     = "admin"     // 管理员：全部权限
+	RoleEditor    = "editor"    // 编辑：管理标签，修改和删除所有内容，删除任意评论
+	RoleAuthor    = "author"    // 作者：发表和管理自己的内容（新注册用户的默认角色）
+	RoleCommenter = "commenter" // 评论者：只能发表和管理自己的评论
+)
+
 type User struct {
-	UserID    uint      `gorm:"primaryKey;autoIncrement" json:"user_id"`
-	Username  string    `gorm:"size:30;not null;uniqueIndex" json:"username"`
-	Password  string    `gorm:"size:100;not null" json:"password"`
-	Email     string    `gorm:"size:100;uniqueIndex" json:"email"`
-	Avatar    string    `gorm:"size:255" json:"avatar"`
-	IsAdmin   bool      `gorm:"not null;default:false" json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	UserID          uint       `gorm:"primaryKey;autoIncrement" json:"user_id"`
+	Username        string     `gorm:"size:30;not null;uniqueIndex" json:"username"`
+	Password        string     `gorm:"size:100;not null" json:"password"`
+	Email           string     `gorm:"size:100;uniqueIndex" json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                     // 邮箱验证通过的时间，nil 表示未验证；修改邮箱后重置
+	TOTPSecret      string     `gorm:"size:128;not null;default:''" json:"-"` // 两步验证的 TOTP 密钥，使用 secretbox 加密存储
+	MFAEnabledAt    *time.Time `json:"mfa_enabled_at"`                        // 启用两步验证的时间，nil 表示未启用
+	Avatar          string     `gorm:"size:255" json:"avatar"`
+	Role            string     `gorm:"size:20;not null;default:'author';index" json:"role"` // 角色，决定拥有的权限，见 rbac 包
+	Language        string     `gorm:"size:10;not null;default:''" json:"language"`         // 界面语言偏好，空表示跟随 Accept-Language
+	BannedAt        *time.Time `gorm:"index" json:"banned_at"`                              // 被管理员封禁的时间，nil 表示正常
+	BanReason       string     `gorm:"size:255;not null;default:''" json:"ban_reason,omitempty"`
+	LockedUntil     *time.Time `gorm:"index" json:"locked_until"` // 多次登录失败后被临时锁定到的时间，nil 或已过去表示未锁定
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	// 关联关系
 	OAuthAccounts []OAuthAccount `gorm:"foreignKey:UserID;references:UserID" json:"oauth_accounts,omitempty"`
 }
 
+// EmailVerified 邮箱是否已验证
+func (u *User) EmailVerified() bool {
+	return u.Email != "" && u.EmailVerifiedAt != nil
+}
+
+// MFAEnabled 是否已启用两步验证
+func (u *User) MFAEnabled() bool {
+	return u.MFAEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsLocked 是否因多次登录失败处于临时锁定中
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(time.Now())
+}
+
+// IsBanned 是否已被封禁
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"max=64"` // 可选的设备名，显示在会话列表中
 }
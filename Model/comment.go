@@ -7,6 +7,7 @@ type Comment struct {
 	CommentText string    `gorm:"type:text;not null" json:"comment_text"` // 重命名避免冲突
 	UserID      uint      `gorm:"not null" json:"user_id"`
 	ContentID   uint      `gorm:"not null" json:"content_id"`
+	ParentID    *uint     `gorm:"index:idx_comments_parent_id" json:"parent_id"` // 回复的评论ID，顶级评论为空
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 
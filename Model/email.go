@@ -2,12 +2,16 @@ package Model
 
 import "time"
 
+// EmailVerify 邮件投递记录：每发送一封邮件记录一条，验证码只保存哈希
 type EmailVerify struct {
 	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Email      string    `gorm:"type:varchar(255);not null" json:"email"`
+	Email      string    `gorm:"type:varchar(255);not null;index" json:"email"`
 	VerifyCode string    `gorm:"type:varchar(100);not null" json:"verify_code"`
 	Purpose    string    `gorm:"type:varchar(50);default:'registration'" json:"purpose"` // 替代 enum
 	IsUsed     bool      `gorm:"default:false" json:"is_used"`
+	Status     string    `gorm:"type:varchar(20);not null;default:'sent'" json:"status"` // sent / failed
+	Transport  string    `gorm:"type:varchar(20);not null;default:''" json:"transport"`
+	Error      string    `gorm:"type:varchar(500);not null;default:''" json:"error,omitempty"` // 发送失败的原因
 	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	ExpiresAt  time.Time `json:"expires_at"`
 }
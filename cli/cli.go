@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `用法: blog [-config 配置文件] <命令> [参数]
+
+命令:
+  serve                         启动HTTP服务（默认）
+  migrate up                    执行所有未执行的迁移
+  migrate down [-steps N]       回滚最近 N 个迁移（默认1）
+  migrate status                查看迁移状态
+  user create-admin             创建管理员账号
+  user reset-password           重置用户密码
+  user disable-mfa              关闭用户的两步验证（丢失验证器时使用）
+  user set-role                 修改用户角色（admin / editor / author / commenter）
+  oauth add-platform            添加或更新OAuth平台配置
+  seed                          写入示例数据（标签、欢迎文章）
+
+使用 "blog <命令> -h" 查看命令参数
+`
+
+// Run 解析命令行参数并执行对应的子命令
+func Run(args []string) error {
+	fs := flag.NewFlagSet("blog", flag.ContinueOnError)
+	configPath := fs.String("config", "", "配置文件路径（默认读取 BLOG_CONFIG 或 "+config.DefaultConfigPath+"）")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := logger.Init(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		return err
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return runServe(nil)
+	}
+
+	switch rest[0] {
+	case "serve":
+		return runServe(rest[1:])
+	case "migrate":
+		return runMigrate(rest[1:])
+	case "user":
+		return runUser(rest[1:])
+	case "oauth":
+		return runOAuth(rest[1:])
+	case "seed":
+		return runSeed(rest[1:])
+	case "help", "-h", "--help":
+		fs.Usage()
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("未知命令: %s", rest[0])
+	}
+}
+
+// openDB 管理命令共用：连接数据库（不执行迁移）
+func openDB() {
+	database.InitDB()
+}
+
+// subcommand 取出子命令名，缺失时返回错误
+func subcommand(group string, args []string, names ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%s 缺少子命令，可选: %v", group, names)
+	}
+	for _, n := range names {
+		if args[0] == n {
+			return n, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s 未知子命令: %s，可选: %v", group, args[0], names)
+}
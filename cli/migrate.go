@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"blog/database"
+	"blog/migrations"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// runMigrate migrate up/down/status
+func runMigrate(args []string) error {
+	name, rest, err := subcommand("migrate", args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "up":
+		openDB()
+		ran, err := migrations.Up(database.DB)
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("没有需要执行的迁移")
+			return nil
+		}
+		fmt.Printf("已执行 %d 个迁移\n", len(ran))
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "回滚的迁移数量")
+		if err := fs.Parse(rest); err != nil {
+			return err
+		}
+		openDB()
+		rolled, err := migrations.Down(database.DB, *steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已回滚 %d 个迁移\n", len(rolled))
+
+	case "status":
+		openDB()
+		list, err := migrations.GetStatus(database.DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range list {
+			status, appliedAt := "pending", "-"
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"blog/Model"
+	"blog/service"
+	"flag"
+	"fmt"
+)
+
+// runOAuth oauth add-platform
+func runOAuth(args []string) error {
+	_, rest, err := subcommand("oauth", args, "add-platform")
+	if err != nil {
+		return err
+	}
+	return addPlatform(rest)
+}
+
+// addPlatform 添加或更新OAuth平台配置（替代 POST /oauth/admin/init-github）
+func addPlatform(args []string) error {
+	fs := flag.NewFlagSet("oauth add-platform", flag.ContinueOnError)
+	platform := fs.String("platform", "github", "平台名称（github/google/wechat）")
+	displayName := fs.String("display-name", "", "显示名称")
+	clientID := fs.String("client-id", "", "Client ID（必填）")
+	clientSecret := fs.String("client-secret", "", "Client Secret（必填）")
+	redirectURL := fs.String("redirect-url", "", "回调地址（必填）")
+	authURL := fs.String("auth-url", "", "授权地址（默认按平台填写）")
+	tokenURL := fs.String("token-url", "", "token地址（默认按平台填写）")
+	userInfoURL := fs.String("user-info-url", "", "用户信息地址（默认按平台填写）")
+	scopes := fs.String("scopes", "", "scope，逗号分隔（默认按平台填写）")
+	iconURL := fs.String("icon-url", "", "图标地址")
+	sortOrder := fs.Int("sort-order", 0, "排序")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *clientID == "" || *clientSecret == "" || *redirectURL == "" {
+		return fmt.Errorf("-client-id、-client-secret、-redirect-url 均不能为空")
+	}
+
+	openDB()
+
+	p := Model.OAuthPlatform{
+		Platform:     *platform,
+		DisplayName:  *displayName,
+		ClientID:     *clientID,
+		ClientSecret: *clientSecret,
+		RedirectURL:  *redirectURL,
+		AuthURL:      *authURL,
+		TokenURL:     *tokenURL,
+		UserInfoURL:  *userInfoURL,
+		Scopes:       *scopes,
+		IconURL:      *iconURL,
+		SortOrder:    *sortOrder,
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = p.Platform
+	}
+
+	created, err := service.NewOAuthService().SavePlatform(&p)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Printf("已添加OAuth平台 %s (ID: %d)\n", p.Platform, p.OAuthID)
+	} else {
+		fmt.Printf("已更新OAuth平台 %s 的配置\n", p.Platform)
+	}
+	return nil
+}
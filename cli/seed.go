@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"blog/Model"
+	"blog/database"
+	"flag"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// runSeed 写入示例数据，可重复执行（已存在的数据会跳过）
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	withContent := fs.Bool("content", true, "没有任何文章时创建一篇欢迎文章")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	openDB()
+
+	tags := []Model.Tag{
+		{TagName: "Go", TagAlias: "golang", Description: "Go 语言相关", Color: "#00ADD8", DisplayOrder: 1},
+		{TagName: "前端", TagAlias: "frontend", Description: "前端开发", Color: "#F7DF1E", DisplayOrder: 2},
+		{TagName: "数据库", TagAlias: "database", Description: "数据库与存储", Color: "#336791", DisplayOrder: 3},
+		{TagName: "随笔", TagAlias: "notes", Description: "日常随笔", Color: "#999999", DisplayOrder: 4},
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		created := 0
+		for i := range tags {
+			result := tx.Where("tag_name = ?", tags[i].TagName).FirstOrCreate(&tags[i])
+			if result.Error != nil {
+				return fmt.Errorf("创建标签 %s 失败: %v", tags[i].TagName, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				created++
+			}
+		}
+		fmt.Printf("标签: 新建 %d 个，共 %d 个\n", created, len(tags))
+
+		if !*withContent {
+			return nil
+		}
+
+		var count int64
+		tx.Model(&Model.Content{}).Count(&count)
+		if count > 0 {
+			fmt.Println("已存在文章，跳过欢迎文章")
+			return nil
+		}
+
+		var admin Model.User
+		if err := tx.Where("role = ?", Model.RoleAdmin).Order("user_id ASC").First(&admin).Error; err != nil {
+			return fmt.Errorf("没有管理员账号，请先执行 user create-admin")
+		}
+
+		now := time.Now()
+		content := Model.Content{
+			Title:             "欢迎来到博客",
+			Content:           "这是一篇由 seed 命令生成的示例文章，可以在后台修改或删除。",
+			BriefIntroduction: "示例文章",
+			UserID:            admin.UserID,
+			Status:            "published",
+			PublishedAt:       &now,
+		}
+		if err := tx.Create(&content).Error; err != nil {
+			return fmt.Errorf("创建欢迎文章失败: %v", err)
+		}
+		if err := tx.Create(&Model.ContentTag{ContentID: content.ID, TagID: tags[len(tags)-1].TagID}).Error; err != nil {
+			return err
+		}
+
+		fmt.Printf("已创建欢迎文章 (ID: %d)\n", content.ID)
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"blog/config"
+	"blog/controller"
+	"blog/database"
+	"blog/lifecycle"
+	"blog/mail"
+	"blog/migrations"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runServe 启动HTTP服务，收到 SIGINT/SIGTERM 后优雅关闭
+func runServe(args []string) error {
+	cfg := config.Cfg.Server
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", cfg.Addr, "监听地址")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 初始化DB/Redis，关闭钩子倒序执行：先注册的最后关闭
+	openDB()
+	lifecycle.OnShutdown("database", func(ctx context.Context) error {
+		return database.CloseDB()
+	})
+	// KV 不可用时登录、验证码等功能都无法工作，直接启动失败
+	if err := database.InitKV(); err != nil {
+		_ = lifecycle.Shutdown(context.Background())
+		return err
+	}
+	lifecycle.OnShutdown("kv", func(ctx context.Context) error {
+		return database.CloseKV()
+	})
+	// 先等待后台邮件发送完成（写投递记录需要数据库）
+	lifecycle.OnShutdown("mail", func(ctx context.Context) error {
+		return mail.Pending.Wait(ctx)
+	})
+
+	// 执行未完成的数据库迁移
+	if _, err := migrations.Up(database.DB); err != nil {
+		return err
+	}
+	database.InitDefaultAdmin()
+
+	// 使用 controller 提供的引擎（已注册路由）
+	router := controller.InitializeServer()
+
+	// 打印已注册路由用于调试
+	for _, r := range router.Routes() {
+		slog.Debug("注册路由", "method", r.Method, "path", r.Path)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           router,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 启动
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP服务已启动", "addr", *addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// 启动失败时也要释放已打开的资源
+		_ = lifecycle.Shutdown(context.Background())
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("收到退出信号，开始优雅关闭", "delay", cfg.ShutdownDelay.String(), "timeout", cfg.ShutdownTimeout.String())
+
+	// 先拒绝新的上传并让 /readyz 返回 503，等负载均衡摘除实例后再停止接收新连接
+	lifecycle.BeginShutdown()
+	if cfg.ShutdownDelay > 0 {
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	// 停止接收新连接并等待进行中的请求
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP服务关闭超时", "error", err)
+	}
+	if err := controller.ActiveUploads.Wait(shutdownCtx); err != nil {
+		slog.Warn("等待上传完成超时", "error", err)
+	}
+
+	// 停止后台任务并关闭 Redis、数据库
+	if err := lifecycle.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	slog.Info("服务已退出")
+	return nil
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"blog/Model"
+	"blog/database"
+	"blog/i18n"
+	"blog/policy"
+	"blog/rbac"
+	"blog/service"
+	"blog/session"
+	"blog/utils"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// runUser user create-admin / reset-password / disable-mfa / set-role
+func runUser(args []string) error {
+	name, rest, err := subcommand("user", args, "create-admin", "reset-password", "disable-mfa", "set-role")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "create-admin":
+		return createAdmin(rest)
+	case "reset-password":
+		return resetPassword(rest)
+	case "disable-mfa":
+		return disableMFA(rest)
+	case "set-role":
+		return setRole(rest)
+	}
+	return nil
+}
+
+// createAdmin 创建管理员账号，未指定密码时随机生成
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "用户名（必填）")
+	email := fs.String("email", "", "邮箱")
+	password := fs.String("password", "", "密码（为空时随机生成）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username 不能为空")
+	}
+
+	openDB()
+
+	var count int64
+	database.DB.Model(&Model.User{}).Where("username = ?", *username).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("用户 %s 已存在，如需修改密码请使用 user reset-password", *username)
+	}
+
+	plain := *password
+	if plain == "" {
+		plain = database.GenerateMixedCode(12)
+	} else if err := checkPassword(plain, *username); err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(plain)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %v", err)
+	}
+
+	admin := Model.User{
+		Username: *username,
+		Password: hashed,
+		Email:    *email,
+		Role:     Model.RoleAdmin,
+	}
+	// 邮箱由运维人员直接指定，视为已验证
+	if admin.Email != "" {
+		now := time.Now()
+		admin.EmailVerifiedAt = &now
+	}
+	if err := database.DB.Create(&admin).Error; err != nil {
+		return fmt.Errorf("创建管理员失败: %v", err)
+	}
+
+	fmt.Printf("已创建管理员 —— ID: %d  用户名: %s  密码: %s\n", admin.UserID, admin.Username, plain)
+	return nil
+}
+
+// resetPassword 重置指定用户的密码，未指定密码时随机生成
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	username := fs.String("username", "", "用户名（必填）")
+	password := fs.String("password", "", "新密码（为空时随机生成）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username 不能为空")
+	}
+
+	openDB()
+
+	var user Model.User
+	if err := database.DB.Where("username = ?", *username).First(&user).Error; err != nil {
+		return fmt.Errorf("用户 %s 不存在", *username)
+	}
+
+	plain := *password
+	if plain == "" {
+		plain = database.GenerateMixedCode(12)
+	} else if err := checkPassword(plain, user.Username); err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(plain)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %v", err)
+	}
+
+	if err := database.DB.Model(&user).Update("password", hashed).Error; err != nil {
+		return fmt.Errorf("更新密码失败: %v", err)
+	}
+
+	fmt.Printf("已重置用户 %s 的密码: %s\n", user.Username, plain)
+
+	// 注销该用户在所有设备上的登录
+	if err := database.InitKV(); err != nil {
+		fmt.Printf("警告: 无法连接KV存储，已登录的设备不会被注销: %v\n", err)
+		return nil
+	}
+	defer database.CloseKV()
+	revoked, err := session.RevokeAll(user.UserID, "")
+	if err != nil {
+		return fmt.Errorf("注销登录失败: %v", err)
+	}
+	fmt.Printf("已注销 %d 个登录设备\n", revoked)
+	return nil
+}
+
+// checkPassword 检查命令行指定的密码是否符合 password_policy，列出全部违反的规则
+func checkPassword(password, username string) error {
+	violations := policy.Password(password, username)
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		msgs = append(msgs, v.Message(i18n.DefaultLanguage))
+	}
+	return fmt.Errorf("密码不符合规则: %s", strings.Join(msgs, "；"))
+}
+
+// disableMFA 关闭指定用户的两步验证，用于用户丢失验证器和恢复码时的人工处理
+func disableMFA(args []string) error {
+	fs := flag.NewFlagSet("user disable-mfa", flag.ContinueOnError)
+	username := fs.String("username", "", "用户名（必填）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username 不能为空")
+	}
+
+	openDB()
+
+	var user Model.User
+	if err := database.DB.Where("username = ?", *username).First(&user).Error; err != nil {
+		return fmt.Errorf("用户 %s 不存在", *username)
+	}
+	if !user.MFAEnabled() {
+		return fmt.Errorf("用户 %s 未启用两步验证", user.Username)
+	}
+	// 同时清理该用户的验证码错误次数等临时数据
+	if err := database.InitKV(); err != nil {
+		fmt.Printf("警告: 无法连接KV存储，两步验证的临时数据将在过期后自动清除: %v\n", err)
+	} else {
+		defer database.CloseKV()
+	}
+	if err := service.DisableMFA(user.UserID); err != nil {
+		return fmt.Errorf("关闭两步验证失败: %v", err)
+	}
+
+	fmt.Printf("已关闭用户 %s 的两步验证\n", user.Username)
+	return nil
+}
+
+// setRole 修改指定用户的角色
+func setRole(args []string) error {
+	fs := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	username := fs.String("username", "", "用户名（必填）")
+	role := fs.String("role", "", "角色（必填）: "+strings.Join(rbac.RoleNames(), ", "))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username 不能为空")
+	}
+	if !rbac.ValidRole(*role) {
+		return fmt.Errorf("-role 必须是以下之一: %s", strings.Join(rbac.RoleNames(), ", "))
+	}
+
+	openDB()
+
+	var user Model.User
+	if err := database.DB.Where("username = ?", *username).First(&user).Error; err != nil {
+		return fmt.Errorf("用户 %s 不存在", *username)
+	}
+	if err := database.DB.Model(&user).Update("role", *role).Error; err != nil {
+		return fmt.Errorf("修改角色失败: %v", err)
+	}
+
+	fmt.Printf("已将用户 %s 的角色修改为 %s\n", user.Username, *role)
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		secret  string
+		wantErr string
+	}{
+		{"release with sample secret", "release", "change-me-in-production", "示例配置"},
+		{"debug with sample secret", "debug", "change-me-in-production", ""},
+		{"release with real secret", "release", "0123456789abcdef0123", ""},
+		{"short secret", "debug", "short", "长度至少16位"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Server.Mode = tt.mode
+			cfg.JWT.Secret = tt.secret
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
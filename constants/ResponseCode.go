@@ -1,326 +1,190 @@
 package constants
 
+import (
+	"blog/i18n"
+	"fmt"
+)
+
+// BaseResponse 统一响应结构
+//
+//	code    唯一的字符串错误码，成功时为 "ok"，客户端应根据它判断错误类型
+//	status  HTTP 状态码
+//	message 面向用户的提示信息，按请求协商的语言翻译
+//	data    业务数据，失败时通常为 null
+//	errors  参数校验失败时的字段级错误
 type BaseResponse struct {
-	Code    int
-	Message string
-	Data    interface{}
+	Code    string       `json:"code"`
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data"`
+	Errors  []FieldError `json:"errors,omitempty"`
 }
 
+// StatusCode 错误目录中的一项
 type StatusCode interface {
-	GetCode() int
-	GetMessage() string
+	GetCode() int                // HTTP 状态码
+	GetKey() string              // 唯一字符串码，例如 content.not_found
+	GetMessage() string          // 默认语言的提示信息
+	Localize(lang string) string // 指定语言的提示信息
 }
 
-type UserStatusCode int
-
-const (
-	UserSuccess      UserStatusCode = 200
-	UserCreated      UserStatusCode = 201
-	UserBadRequest   UserStatusCode = 400
-	UserUnauthorized UserStatusCode = 401
-	UserForbidden    UserStatusCode = 403
-	UserNotFound     UserStatusCode = 404
-	UserConflict     UserStatusCode = 409
-	UserLoginError   UserStatusCode = 422
-	UserSystemError  UserStatusCode = 500
-	UserRedisError   UserStatusCode = 503
-)
-
-func (u UserStatusCode) GetCode() int {
-	return int(u)
+// ErrorCode 错误目录条目，只能通过 define 创建以保证字符串码唯一。
+// 提示信息以字符串码为 key 放在 i18n/locales 的语言包中
+type ErrorCode struct {
+	key    string
+	status int
 }
 
-func (u UserStatusCode) GetMessage() string {
-	switch u {
-	case UserSuccess:
-		return "登录成功"
-	case UserCreated:
-		return "注册成功"
-	case UserBadRequest:
-		return "参数错误"
-	case UserUnauthorized:
-		return "未授权"
-	case UserForbidden:
-		return "禁止访问"
-	case UserNotFound:
-		return "用户不存在"
-	case UserConflict:
-		return "用户已存在"
-	case UserLoginError:
-		return "账号或密码错误"
-	case UserSystemError:
-		return "系统错误"
-	case UserRedisError:
-		return "服务器服务异常"
-	default:
-		return "未知错误"
-	}
-}
-
-// 通用状态码
-type CommonStatusCode int
+func (e ErrorCode) GetCode() int       { return e.status }
+func (e ErrorCode) GetKey() string     { return e.key }
+func (e ErrorCode) GetMessage() string { return e.Localize(i18n.DefaultLanguage) }
 
-const (
-	Success      CommonStatusCode = 200
-	Created      CommonStatusCode = 201
-	BadRequest   CommonStatusCode = 400
-	Unauthorized CommonStatusCode = 401
-	Forbidden    CommonStatusCode = 403
-	NotFound     CommonStatusCode = 404
-	Conflict     CommonStatusCode = 409
-	SystemError  CommonStatusCode = 500
-)
+func (e ErrorCode) Localize(lang string) string {
+	return i18n.T(lang, e.key)
+}
 
-func (c CommonStatusCode) GetCode() int { return int(c) }
+// catalog 所有已定义的错误码
+var catalog = map[string]ErrorCode{}
 
-func (c CommonStatusCode) GetMessage() string {
-	switch c {
-	case Success:
-		return "success"
-	case Created:
-		return "created"
-	case BadRequest:
-		return "bad request"
-	case Unauthorized:
-		return "unauthorized"
-	case Forbidden:
-		return "forbidden"
-	case NotFound:
-		return "not found"
-	case Conflict:
-		return "conflict"
-	case SystemError:
-		return "system error"
-	default:
-		return "unknown status"
+func define(key string, status int) ErrorCode {
+	if _, exists := catalog[key]; exists {
+		panic(fmt.Sprintf("constants: duplicate error code %q", key))
 	}
-}
-
-func BuildResponseWithStatus(status StatusCode, data interface{}) BaseResponse {
-	return BaseResponse{
-		Code:    status.GetCode(),
-		Message: status.GetMessage(),
-		Data:    data,
+	if _, ok := i18n.Lookup(i18n.DefaultLanguage, key); !ok {
+		panic(fmt.Sprintf("constants: error code %q has no %s message", key, i18n.DefaultLanguage))
 	}
+	e := ErrorCode{key: key, status: status}
+	catalog[key] = e
+	return e
 }
 
-// ContentStatusCode 内容相关状态码
-type ContentStatusCode int
-
-const (
-	ContentSuccess      ContentStatusCode = 200
-	ContentCreated      ContentStatusCode = 201
-	ContentBadRequest   ContentStatusCode = 400
-	ContentUnauthorized ContentStatusCode = 401
-	ContentForbidden    ContentStatusCode = 403
-	ContentNotFound     ContentStatusCode = 404
-	ContentConflict     ContentStatusCode = 409
-	ContentSystemError  ContentStatusCode = 500
-)
-
-func (c ContentStatusCode) GetCode() int { return int(c) }
-
-func (c ContentStatusCode) GetMessage() string {
-	switch c {
-	case ContentSuccess:
-		return "操作成功"
-	case ContentCreated:
-		return "创建成功"
-	case ContentBadRequest:
-		return "参数错误"
-	case ContentUnauthorized:
-		return "未授权"
-	case ContentForbidden:
-		return "禁止访问"
-	case ContentNotFound:
-		return "内容不存在"
-	case ContentConflict:
-		return "冲突错误"
-	case ContentSystemError:
-		return "系统错误"
-	default:
-		return "未知错误"
+// Catalog 返回全部错误码（用于文档和排查）
+func Catalog() []ErrorCode {
+	list := make([]ErrorCode, 0, len(catalog))
+	for _, e := range catalog {
+		list = append(list, e)
 	}
+	return list
 }
 
-// CommentStatusCode 评论相关状态码
-type CommentStatusCode int
-
-const (
-	CommentSuccess      CommentStatusCode = 200
-	CommentCreated      CommentStatusCode = 201
-	CommentBadRequest   CommentStatusCode = 400
-	CommentUnauthorized CommentStatusCode = 401
-	CommentForbidden    CommentStatusCode = 403
-	CommentNotFound     CommentStatusCode = 404
-	CommentConflict     CommentStatusCode = 409
-	CommentSystemError  CommentStatusCode = 500
+// 通用
+var (
+	Success            = define("ok", 200)
+	Created            = define("created", 201)
+	BadRequest         = define("request.invalid", 400)
+	ValidationFailed   = define("request.validation_failed", 400)
+	RateLimited        = define("request.rate_limited", 429)
+	Unauthorized       = define("auth.unauthorized", 401)
+	Forbidden          = define("auth.forbidden", 403)
+	NotFound           = define("resource.not_found", 404)
+	SystemError        = define("internal.error", 500)
+	ServiceUnavailable = define("internal.unavailable", 503)
+	ShuttingDown       = define("internal.shutting_down", 503)
 )
 
-func (c CommentStatusCode) GetCode() int { return int(c) }
-
-func (c CommentStatusCode) GetMessage() string {
-	switch c {
-	case CommentSuccess:
-		return "操作成功"
-	case CommentCreated:
-		return "创建成功"
-	case CommentBadRequest:
-		return "参数错误"
-	case CommentUnauthorized:
-		return "未授权"
-	case CommentForbidden:
-		return "禁止访问"
-	case CommentNotFound:
-		return "评论不存在"
-	case CommentConflict:
-		return "冲突错误"
-	case CommentSystemError:
-		return "系统错误"
-	default:
-		return "未知错误"
-	}
-}
-
-// TagStatusCode 标签相关状态码
-type TagStatusCode int
-
-const (
-	TagSuccess      TagStatusCode = 200
-	TagCreated      TagStatusCode = 201
-	TagBadRequest   TagStatusCode = 400
-	TagUnauthorized TagStatusCode = 401
-	TagForbidden    TagStatusCode = 403
-	TagNotFound     TagStatusCode = 404
-	TagConflict     TagStatusCode = 409
-	TagSystemError  TagStatusCode = 500
+// 认证
+var (
+	AuthTokenMissing          = define("auth.token_missing", 401)
+	AuthTokenMalformed        = define("auth.token_malformed", 401)
+	AuthTokenInvalid          = define("auth.token_invalid", 401)
+	AuthTokenRevoked          = define("auth.token_revoked", 401)
+	AuthRefreshInvalid        = define("auth.refresh_token_invalid", 401)
+	AuthRefreshReused         = define("auth.refresh_token_reused", 401)
+	AuthInvalidCredentials    = define("auth.invalid_credentials", 401)
+	AuthMFAEnrollmentRequired = define("auth.mfa_enrollment_required", 403)
+	AuthUserBanned            = define("auth.user_banned", 403)
+	AuthLoginThrottled        = define("auth.login_throttled", 429)
+	AuthAccountLocked         = define("auth.account_locked", 423)
+	AuthUnlockTokenInvalid    = define("auth.unlock_token_invalid", 400)
 )
 
-func (t TagStatusCode) GetCode() int { return int(t) }
-
-func (t TagStatusCode) GetMessage() string {
-	switch t {
-	case TagSuccess:
-		return "操作成功"
-	case TagCreated:
-		return "创建成功"
-	case TagBadRequest:
-		return "参数错误"
-	case TagUnauthorized:
-		return "未授权"
-	case TagForbidden:
-		return "禁止访问"
-	case TagNotFound:
-		return "标签不存在"
-	case TagConflict:
-		return "冲突错误"
-	case TagSystemError:
-		return "系统错误"
-	default:
-		return "未知错误"
-	}
-}
-
-// EmailStatusCode 邮件相关状态码
-type EmailStatusCode int
-
-const (
-	EmailSuccess      EmailStatusCode = 200
-	EmailCreated      EmailStatusCode = 201
-	EmailBadRequest   EmailStatusCode = 400
-	EmailUnauthorized EmailStatusCode = 401
-	EmailForbidden    EmailStatusCode = 403
-	EmailNotFound     EmailStatusCode = 404
-	EmailConflict     EmailStatusCode = 409
-	EmailSystemError  EmailStatusCode = 500
+// 会话
+var (
+	SessionNotFound = define("session.not_found", 404)
 )
 
-func (e EmailStatusCode) GetCode() int { return int(e) }
+// 两步验证
+var (
+	MFACodeInvalid      = define("mfa.code_invalid", 400)
+	MFAChallengeInvalid = define("mfa.challenge_invalid", 401)
+	MFAAlreadyEnabled   = define("mfa.already_enabled", 409)
+	MFANotEnabled       = define("mfa.not_enabled", 400)
+	MFASetupExpired     = define("mfa.setup_expired", 400)
+	MFARequired         = define("mfa.required", 403)
+	MFATooManyAttempts  = define("mfa.too_many_attempts", 429)
+)
 
-func (e EmailStatusCode) GetMessage() string {
-	switch e {
-	case EmailSuccess:
-		return "操作成功"
-	case EmailCreated:
-		return "发送成功"
-	case EmailBadRequest:
-		return "参数错误"
-	case EmailUnauthorized:
-		return "未授权"
-	case EmailForbidden:
-		return "禁止访问"
-	case EmailNotFound:
-		return "邮件记录不存在"
-	case EmailConflict:
-		return "冲突错误"
-	case EmailSystemError:
-		return "系统错误"
-	default:
-		return "未知错误"
-	}
-}
+// 用户
+var (
+	UserNotFound          = define("user.not_found", 404)
+	UserExists            = define("user.already_exists", 409)
+	UserPasswordIncorrect = define("user.password_incorrect", 400)
+	UserForbidden         = define("user.forbidden", 403)
+	UserBanSelf           = define("user.ban_self", 400)
+	UserRoleSelf          = define("user.role_self", 400)
+	UserEmailExists       = define("user.email_exists", 409)
+	UserEmailMissing      = define("user.email_missing", 400)
+	UserEmailVerified     = define("user.email_already_verified", 409)
+)
 
-type FileStatusCode int
+// 内容
+var (
+	ContentNotFound  = define("content.not_found", 404)
+	ContentForbidden = define("content.forbidden", 403)
+)
 
-const (
-	FFileUploadSuccess FileStatusCode = 200
-	FileUploadFailed   FileStatusCode = 410
+// 评论
+var (
+	CommentNotFound      = define("comment.not_found", 404)
+	CommentForbidden     = define("comment.forbidden", 403)
+	CommentParentInvalid = define("comment.parent_invalid", 400)
 )
 
-func (f FileStatusCode) GetCode() int { return int(f) }
+// 标签
+var (
+	TagNotFound = define("tag.not_found", 404)
+	TagExists   = define("tag.already_exists", 409)
+)
 
-func (f FileStatusCode) GetMessage() string {
-	switch f {
-	case FFileUploadSuccess:
-		return "文件上传成功"
-	case FileUploadFailed:
-		return "文件上传失败"
-	default:
-		return "未知错误"
-	}
-}
+// 邮件
+var (
+	EmailCodeInvalid          = define("email.code_invalid", 400)
+	EmailCodeAttemptsExceeded = define("email.code_attempts_exceeded", 429)
+	EmailTooFrequent          = define("email.too_frequent", 429)
+	EmailSendFailed           = define("email.send_failed", 500)
+)
 
-// OAuthStatusCode OAuth相关状态码
-type OAuthStatusCode int
+// 文件
+var (
+	FileMissing        = define("file.missing", 400)
+	FileTooLarge       = define("file.too_large", 413)
+	FileTypeNotAllowed = define("file.type_not_allowed", 400)
+	FileUploadFailed   = define("file.upload_failed", 500)
+)
 
-const (
-	OAuthSuccess          OAuthStatusCode = 200
-	OAuthRedirect         OAuthStatusCode = 302
-	OAuthBadRequest       OAuthStatusCode = 400
-	OAuthUnauthorized     OAuthStatusCode = 401
-	OAuthForbidden        OAuthStatusCode = 403
-	OAuthNotFound         OAuthStatusCode = 404
-	OAuthConflict         OAuthStatusCode = 409
-	OAuthCallbackError    OAuthStatusCode = 422
-	OAuthSystemError      OAuthStatusCode = 500
-	OAuthPlatformDisabled OAuthStatusCode = 503
+// OAuth
+var (
+	OAuthPlatformNotFound = define("oauth.platform_not_found", 404)
+	OAuthPlatformDisabled = define("oauth.platform_disabled", 503)
+	OAuthStateInvalid     = define("oauth.state_invalid", 400)
+	OAuthCallbackFailed   = define("oauth.callback_failed", 422)
+	OAuthAccountBound     = define("oauth.account_already_bound", 409)
+	OAuthAccountNotBound  = define("oauth.account_not_bound", 404)
+	OAuthLastLoginMethod  = define("oauth.last_login_method", 400)
+	OAuthEmailConflict    = define("oauth.email_conflict", 409)
 )
 
-func (o OAuthStatusCode) GetCode() int { return int(o) }
+// BuildResponseWithStatus 使用默认语言构建响应
+func BuildResponseWithStatus(status StatusCode, data interface{}) BaseResponse {
+	return BuildLocalizedResponse(i18n.DefaultLanguage, status, data)
+}
 
-func (o OAuthStatusCode) GetMessage() string {
-	switch o {
-	case OAuthSuccess:
-		return "OAuth认证成功"
-	case OAuthRedirect:
-		return "重定向到第三方认证"
-	case OAuthBadRequest:
-		return "OAuth请求参数错误"
-	case OAuthUnauthorized:
-		return "OAuth认证失败"
-	case OAuthForbidden:
-		return "OAuth操作被禁止"
-	case OAuthNotFound:
-		return "OAuth平台不存在"
-	case OAuthConflict:
-		return "该第三方账号已被绑定"
-	case OAuthCallbackError:
-		return "OAuth回调处理失败"
-	case OAuthSystemError:
-		return "OAuth系统错误"
-	case OAuthPlatformDisabled:
-		return "该OAuth平台已禁用"
-	default:
-		return "未知错误"
+// BuildLocalizedResponse 使用指定语言构建响应
+func BuildLocalizedResponse(lang string, status StatusCode, data interface{}) BaseResponse {
+	return BaseResponse{
+		Code:    status.GetKey(),
+		Status:  status.GetCode(),
+		Message: status.Localize(lang),
+		Data:    data,
 	}
 }
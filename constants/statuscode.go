@@ -1,32 +1,24 @@
 package constants
 
-import "github.com/gin-gonic/gin"
+import (
+	"blog/i18n"
 
-func SendResponse(c *gin.Context, status StatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
-}
+	"github.com/gin-gonic/gin"
+)
 
-// 每个控制层的独立包装方法，方便控制层直接调用（保持类型安全与明确性）
-func SendUserResponse(c *gin.Context, status UserStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
-}
-
-func SendContentResponse(c *gin.Context, status ContentStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
-}
-
-func SendCommentResponse(c *gin.Context, status CommentStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
-}
-
-func SendTagResponse(c *gin.Context, status TagStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
+// SendResponse 按请求协商的语言返回统一响应
+func SendResponse(c *gin.Context, status StatusCode, data interface{}) {
+	c.JSON(status.GetCode(), BuildLocalizedResponse(i18n.FromGin(c), status, data))
 }
 
-func SendEmailResponse(c *gin.Context, status EmailStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
+// SendValidationError 请求体绑定失败时返回 request.validation_failed 和字段级错误
+func SendValidationError(c *gin.Context, err error) {
+	SendFieldErrors(c, FieldErrors(i18n.FromGin(c), err))
 }
 
-func SendOAuthResponse(c *gin.Context, status OAuthStatusCode, data interface{}) {
-	c.JSON(status.GetCode(), BuildResponseWithStatus(status, data))
+// SendFieldErrors 返回 request.validation_failed 和给定的字段级错误（message 需已按请求语言翻译）
+func SendFieldErrors(c *gin.Context, errs []FieldError) {
+	resp := BuildLocalizedResponse(i18n.FromGin(c), ValidationFailed, nil)
+	resp.Errors = errs
+	c.JSON(ValidationFailed.GetCode(), resp)
 }
@@ -0,0 +1,112 @@
+package constants
+
+import (
+	"blog/i18n"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // 请求中的字段名（json 名称）
+	Rule    string `json:"rule"`            // 未通过的规则，例如 required、email、min
+	Param   string `json:"param,omitempty"` // 规则参数，例如 min=6 中的 6
+	Message string `json:"message"`
+}
+
+func init() {
+	// 校验错误中使用 json 字段名而不是 Go 结构体字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+
+		// language: 支持的界面语言，空字符串表示不设置
+		_ = v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+			s := fl.Field().String()
+			return s == "" || i18n.Normalize(s) != ""
+		})
+	}
+}
+
+// FieldErrors 将 ShouldBind 返回的错误转换为字段级错误列表，message 使用 lang 翻译
+func FieldErrors(lang string, err error) []FieldError {
+	var (
+		verrs     validator.ValidationErrors
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+
+	switch {
+	case errors.As(err, &verrs):
+		list := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			list = append(list, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: ruleMessage(lang, fe.Tag(), fe.Param(), fe.Kind()),
+			})
+		}
+		return list
+	case errors.As(err, &typeErr):
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: i18n.T(lang, "validation.type", typeErr.Type.String()),
+		}}
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Rule: "json", Message: i18n.T(lang, "validation.json")}}
+	case err != nil && err.Error() == "EOF":
+		return []FieldError{{Rule: "required", Message: i18n.T(lang, "validation.empty_body")}}
+	default:
+		return []FieldError{{Rule: "invalid", Message: i18n.T(lang, "validation.invalid")}}
+	}
+}
+
+// fieldPath 去掉命名空间中的顶层结构体名：LoginRequest.username -> username
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+func ruleMessage(lang, rule, param string, kind reflect.Kind) string {
+	switch rule {
+	case "required", "email", "url":
+		return i18n.T(lang, "validation."+rule)
+	case "language":
+		return i18n.T(lang, "validation.language", strings.Join(i18n.Supported(), ", "))
+	case "oneof", "len":
+		return i18n.T(lang, "validation."+rule, param)
+	case "min", "gte", "max", "lte":
+		bound := "min"
+		if rule == "max" || rule == "lte" {
+			bound = "max"
+		}
+		switch kind {
+		case reflect.String:
+			return i18n.T(lang, "validation."+bound+".string", param)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return i18n.T(lang, "validation."+bound+".list", param)
+		default:
+			return i18n.T(lang, "validation."+bound+".number", param)
+		}
+	default:
+		return i18n.T(lang, "validation.other", rule)
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"blog/config"
+	"blog/metrics"
+	"blog/rbac"
+	"blog/security"
+	"blog/utils"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIPrefix 版本化接口的公共前缀，版本 v1 挂载在 /api/v1
+const APIPrefix = "/api"
+
+// APIVersion 一个 API 版本
+type APIVersion struct {
+	Name   string                 // 版本名，例如 v1，决定挂载路径 /api/<Name>
+	Routes func(*gin.RouterGroup) // 注册该版本的全部路由
+	// DeprecatedAt 非零时该版本整体标记为弃用，响应带 Deprecation 头
+	DeprecatedAt time.Time
+	// Sunset 计划下线时间，非零时响应带 Sunset 头
+	Sunset time.Time
+	// Successor 弃用时的替代版本名，用于生成 Link: rel="successor-version"
+	Successor string
+}
+
+var apiVersions []APIVersion
+
+// registerAPIVersion 登记一个 API 版本，在各版本路由文件的 init 中调用
+func registerAPIVersion(v APIVersion) {
+	for _, existing := range apiVersions {
+		if existing.Name == v.Name {
+			panic(fmt.Sprintf("controller: duplicate api version %q", v.Name))
+		}
+	}
+	apiVersions = append(apiVersions, v)
+	sort.Slice(apiVersions, func(i, j int) bool { return apiVersions[i].Name < apiVersions[j].Name })
+}
+
+// versionPrefix 返回版本的挂载路径，例如 /api/v1
+func versionPrefix(name string) string {
+	return APIPrefix + "/" + name
+}
+
+// mountAPIVersions 将所有已登记的版本挂载到 /api/<version>
+func mountAPIVersions(r *gin.Engine) {
+	for _, v := range apiVersions {
+		prefix := versionPrefix(v.Name)
+		group := r.Group(prefix, security.RateLimitMiddleware(func(path string) string {
+			return strings.TrimPrefix(path, prefix)
+		}))
+		if !v.DeprecatedAt.IsZero() {
+			successor := func(path string) string {
+				if v.Successor == "" {
+					return ""
+				}
+				return versionPrefix(v.Successor) + strings.TrimPrefix(path, versionPrefix(v.Name))
+			}
+			group.Use(deprecated(v.DeprecatedAt, v.Sunset, successor))
+		}
+		v.Routes(group)
+	}
+}
+
+// ============================================================
+// 旧版根路径接口
+// ============================================================
+
+// LegacyDeprecatedAt 根路径接口的弃用时间（/api/v1 发布时间）
+var LegacyDeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+// LegacyVersion 旧版根路径接口对应的新版本
+const LegacyVersion = "v1"
+
+// legacyAliases 旧路径中已在新版本里改名的前缀：旧前缀 -> 新前缀
+var legacyAliases = map[string]string{
+	"/content/content_auth": "/content",
+}
+
+// legacyRoutes 旧版根路径路由（METHOD + 空格 + 路径），生成文档时标记为弃用
+var legacyRoutes = map[string]bool{}
+
+// legacySuccessor 旧路径对应的新版本路径，例如 /content/content_auth/3 -> /api/v1/content/3
+func legacySuccessor(path string) string {
+	return versionPrefix(LegacyVersion) + legacyToVersioned(path)
+}
+
+// legacyToVersioned 去掉旧路径中已改名的部分
+func legacyToVersioned(path string) string {
+	for old, renamed := range legacyAliases {
+		if path == old || strings.HasPrefix(path, old+"/") {
+			return renamed + strings.TrimPrefix(path, old)
+		}
+	}
+	return path
+}
+
+// registerLegacyRoutes 在根路径继续提供旧版接口，供尚未迁移到 /api/v1 的客户端使用
+func registerLegacyRoutes(r *gin.Engine) {
+	before := routeKeys(r)
+
+	legacy := r.Group("",
+		deprecated(LegacyDeprecatedAt, config.Cfg.API.LegacySunset, legacySuccessor),
+		security.RateLimitMiddleware(legacyToVersioned),
+	)
+	for _, v := range apiVersions {
+		if v.Name == LegacyVersion {
+			v.Routes(legacy)
+		}
+	}
+
+	// 旧版内容写接口位于 /content/content_auth，v1 中已合并到 /content
+	authContent := legacy.Group("/content/content_auth")
+	authContent.Use(utils.JWTAuthMiddleware())
+	{
+		authContent.POST("", rbac.RequirePermission(rbac.ContentCreate), CreateContent)
+		authContent.PUT("/:id", UpdateContent)
+		authContent.DELETE("/:id", DeleteContent)
+		authContent.POST("/:id/tags", AddContentTags)
+		authContent.DELETE("/:id/tags/:tagId", RemoveContentTag)
+	}
+
+	for key := range routeKeys(r) {
+		if !before[key] {
+			legacyRoutes[key] = true
+		}
+	}
+}
+
+func routeKeys(r *gin.Engine) map[string]bool {
+	keys := make(map[string]bool)
+	for _, ri := range r.Routes() {
+		keys[ri.Method+" "+ri.Path] = true
+	}
+	return keys
+}
+
+// deprecated 为弃用接口添加响应头（RFC 9745 Deprecation、RFC 8594 Sunset）并统计调用次数
+// successor 返回替代接口的路径，为空时不输出 Link 头
+func deprecated(at, sunset time.Time, successor func(path string) string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", at.Unix())
+	var sunsetHeader string
+	if !sunset.IsZero() {
+		sunsetHeader = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if sunsetHeader != "" {
+			c.Header("Sunset", sunsetHeader)
+		}
+		if next := successor(c.Request.URL.Path); next != "" {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, next))
+		}
+		metrics.DeprecatedRequests.WithLabelValues(c.FullPath()).Inc()
+		c.Next()
+	}
+}
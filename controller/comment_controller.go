@@ -4,6 +4,9 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"blog/metrics"
+	"blog/rbac"
+	"blog/service"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -14,14 +17,14 @@ import (
 func CreateComment(c *gin.Context) {
 	var comment Model.Comment
 	if err := c.ShouldBindJSON(&comment); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	// 设置评论者ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "未获取到用户信息"})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	comment.UserID = uint(userID.(int64))
@@ -29,14 +32,25 @@ func CreateComment(c *gin.Context) {
 	// 验证内容是否存在
 	var content Model.Content
 	if err := database.DB.First(&content, comment.ContentID).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "评论的内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
 		return
 	}
 
+	// 回复评论时，父评论必须属于同一篇内容
+	if comment.ParentID != nil {
+		var parent Model.Comment
+		if err := database.DB.First(&parent, *comment.ParentID).Error; err != nil || parent.ContentID != comment.ContentID {
+			constants.SendResponse(c, constants.CommentParentInvalid, nil)
+			return
+		}
+	}
+
 	if err := database.DB.Create(&comment).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
+	metrics.CommentsCreated.Inc()
+	service.NotifyNewComment(&comment, &content)
 
 	constants.SendResponse(c, constants.Success, comment)
 }
@@ -46,7 +60,7 @@ func ListContentComments(c *gin.Context) {
 	contentIDStr := c.Param("contentId")
 	contentID, err := strconv.ParseUint(contentIDStr, 10, 32)
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": "无效的内容ID"})
+		constants.SendResponse(c, constants.BadRequest, nil)
 		return
 	}
 
@@ -55,42 +69,43 @@ func ListContentComments(c *gin.Context) {
 	if err := database.DB.Preload("User", func(db *gorm.DB) *gorm.DB {
 		return db.Select("user_id", "username")
 	}).Where("content_id = ?", uint(contentID)).Find(&comments).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
 	constants.SendResponse(c, constants.Success, comments)
 }
 
+// updateCommentRequest 更新评论的请求体
+type updateCommentRequest struct {
+	CommentText string `json:"content" binding:"required"`
+}
+
 // UpdateComment 更新评论
 func UpdateComment(c *gin.Context) {
 	id := c.Param("id")
 	var existingComment Model.Comment
 
 	if err := database.DB.First(&existingComment, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "评论不存在"})
+		constants.SendResponse(c, constants.CommentNotFound, nil)
 		return
 	}
 
-	// 验证是否为评论作者
-	userID, _ := c.Get("user_id")
-	if existingComment.UserID != uint(userID.(int64)) {
-		constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权修改他人评论"})
+	// 评论作者本人，或拥有修改所有评论的权限
+	if !rbac.Authorize(c, rbac.CommentUpdate, existingComment.UserID, constants.CommentForbidden) {
 		return
 	}
 
 	// 只更新评论内容
-	var updateData struct {
-		CommentText string `json:"content" binding:"required"`
-	}
+	var updateData updateCommentRequest
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	existingComment.CommentText = updateData.CommentText
 	if err := database.DB.Save(&existingComment).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -103,19 +118,17 @@ func DeleteComment(c *gin.Context) {
 	var comment Model.Comment
 
 	if err := database.DB.First(&comment, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "评论不存在"})
+		constants.SendResponse(c, constants.CommentNotFound, nil)
 		return
 	}
 
-	// 验证是否为评论作者
-	userID, _ := c.Get("user_id")
-	if comment.UserID != uint(userID.(int64)) {
-		constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权删除他人评论"})
+	// 评论作者本人，或拥有删除所有评论的权限（编辑可以删除不当评论）
+	if !rbac.Authorize(c, rbac.CommentDelete, comment.UserID, constants.CommentForbidden) {
 		return
 	}
 
 	if err := database.DB.Delete(&comment).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -4,6 +4,9 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"blog/i18n"
+	"blog/logger"
+	"blog/rbac"
 	"fmt"
 	"strconv"
 
@@ -20,24 +23,35 @@ func getUserID(c *gin.Context) (uint, error) {
 	return uint(userID.(int64)), nil
 }
 
+// localize 按本次请求协商的语言翻译提示信息
+func localize(c *gin.Context, key string, args ...interface{}) string {
+	return i18n.T(i18n.FromGin(c), key, args...)
+}
+
+// sendSystemError 记录内部错误并返回 internal.error，错误详情只写日志不返回给客户端
+func sendSystemError(c *gin.Context, err error) {
+	logger.FromGin(c).Error("请求处理失败", "error", err)
+	constants.SendResponse(c, constants.SystemError, nil)
+}
+
 // CreateContent 创建内容
 func CreateContent(c *gin.Context) {
 	var content Model.Content
 	if err := c.ShouldBindJSON(&content); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	// 获取用户ID
 	userID, err := getUserID(c)
 	if err != nil {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": err.Error()})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	content.UserID = userID
 
 	if err := database.DB.Create(&content).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -75,7 +89,7 @@ func ListContents(c *gin.Context) {
 		Limit(pageSize).
 		Order("created_at DESC").
 		Find(&contents).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -101,11 +115,11 @@ func GetContent(c *gin.Context) {
 			return db.Select("user_id", "username", "avatar")
 		}).
 		Preload("ContentFiles", func(db *gorm.DB) *gorm.DB {
-			return db.Order("`order` ASC")
+			return db.Order(database.Quote("order") + " ASC")
 		}).
 		Preload("ContentFiles.FileRecord").
 		First(&content, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
 		return
 	}
 
@@ -115,38 +129,35 @@ func GetContent(c *gin.Context) {
 	constants.SendResponse(c, constants.Success, content)
 }
 
+// updateContentRequest 更新内容的请求体，只包含允许修改的字段
+type updateContentRequest struct {
+	Title             string `json:"title"`
+	Content           string `json:"content"`
+	BriefIntroduction string `json:"brief_introduction"`
+	CoverImage        string `json:"cover_image"`
+	Status            string `json:"status"`
+}
+
 // UpdateContent 更新内容
 func UpdateContent(c *gin.Context) {
 	id := c.Param("id")
 	var existingContent Model.Content
 
 	if err := database.DB.First(&existingContent, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
 		return
 	}
 
-	// 验证是否为作者
-	userID, err := getUserID(c)
-	if err != nil {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-	if existingContent.UserID != userID {
-		constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权修改他人内容"})
+	// 作者本人，或拥有修改所有内容的权限
+	if !rbac.Authorize(c, rbac.ContentUpdate, existingContent.UserID, constants.ContentForbidden) {
 		return
 	}
 
 	// 只更新允许修改的字段
-	var updateData struct {
-		Title             string `json:"title"`
-		Content           string `json:"content"`
-		BriefIntroduction string `json:"brief_introduction"`
-		CoverImage        string `json:"cover_image"`
-		Status            string `json:"status"`
-	}
+	var updateData updateContentRequest
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
@@ -160,7 +171,7 @@ func UpdateContent(c *gin.Context) {
 	}
 
 	if err := database.DB.Model(&existingContent).Updates(updates).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -173,23 +184,17 @@ func DeleteContent(c *gin.Context) {
 	var content Model.Content
 
 	if err := database.DB.First(&content, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
 		return
 	}
 
-	// 验证是否为作者
-	userID, err := getUserID(c)
-	if err != nil {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-	if content.UserID != userID {
-		constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权删除他人内容"})
+	// 作者本人，或拥有删除所有内容的权限
+	if !rbac.Authorize(c, rbac.ContentDelete, content.UserID, constants.ContentForbidden) {
 		return
 	}
 
 	// 使用事务删除内容及其关联
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		// 删除标签关联
 		if err := tx.Where("content_id = ?", id).Delete(&Model.ContentTag{}).Error; err != nil {
 			return err
@@ -210,35 +215,41 @@ func DeleteContent(c *gin.Context) {
 	})
 
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
 	constants.SendResponse(c, constants.Success, nil)
 }
 
+// contentTagsRequest 为内容添加标签的请求体
+type contentTagsRequest struct {
+	TagIDs []uint `json:"tag_ids" binding:"required"`
+}
+
 // AddContentTags 添加内容标签
 func AddContentTags(c *gin.Context) {
 	contentIDStr := c.Param("id")
 	contentID, err := strconv.ParseUint(contentIDStr, 10, 32)
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": "无效的内容ID"})
+		constants.SendResponse(c, constants.BadRequest, nil)
 		return
 	}
 
 	// 验证内容是否存在
 	var content Model.Content
 	if err := database.DB.First(&content, contentID).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
+		return
+	}
+	if !rbac.Authorize(c, rbac.ContentUpdate, content.UserID, constants.ContentForbidden) {
 		return
 	}
 
 	// 获取标签ID列表
-	var req struct {
-		TagIDs []uint `json:"tag_ids" binding:"required"`
-	}
+	var req contentTagsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
@@ -273,11 +284,11 @@ func AddContentTags(c *gin.Context) {
 	})
 
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
-	constants.SendResponse(c, constants.Success, gin.H{"message": "标签添加成功"})
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.tags_added")})
 }
 
 // RemoveContentTag 移除内容标签
@@ -285,18 +296,27 @@ func RemoveContentTag(c *gin.Context) {
 	contentID := c.Param("id")
 	tagID := c.Param("tagId")
 
+	var content Model.Content
+	if err := database.DB.First(&content, contentID).Error; err != nil {
+		constants.SendResponse(c, constants.ContentNotFound, nil)
+		return
+	}
+	if !rbac.Authorize(c, rbac.ContentUpdate, content.UserID, constants.ContentForbidden) {
+		return
+	}
+
 	result := database.DB.Where("content_id = ? AND tag_id = ?", contentID, tagID).Delete(&Model.ContentTag{})
 	if result.Error != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": result.Error.Error()})
+		sendSystemError(c, result.Error)
 		return
 	}
 
 	if result.RowsAffected == 0 {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "未找到该标签关联"})
+		constants.SendResponse(c, constants.NotFound, nil)
 		return
 	}
 
-	constants.SendResponse(c, constants.Success, gin.H{"message": "标签移除成功"})
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.tag_removed")})
 }
 
 // AddContentFiles 为内容添加文件关联
@@ -309,14 +329,17 @@ func AddContentFiles(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	// 验证内容是否存在
 	var content Model.Content
 	if err := database.DB.First(&content, contentID).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "内容不存在"})
+		constants.SendResponse(c, constants.ContentNotFound, nil)
+		return
+	}
+	if !rbac.Authorize(c, rbac.ContentUpdate, content.UserID, constants.ContentForbidden) {
 		return
 	}
 
@@ -326,7 +349,7 @@ func AddContentFiles(c *gin.Context) {
 		var maxOrder int
 		tx.Model(&Model.ContentFile{}).
 			Where("content_id = ?", contentID).
-			Select("COALESCE(MAX(`order`), -1)").
+			Select("COALESCE(MAX(" + database.Quote("order") + "), -1)").
 			Scan(&maxOrder)
 
 		for i, fileID := range req.FileIDs {
@@ -350,11 +373,11 @@ func AddContentFiles(c *gin.Context) {
 	})
 
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
-	constants.SendResponse(c, constants.Success, gin.H{"message": "文件关联成功"})
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.files_linked")})
 }
 
 // RemoveContentFile 移除内容的文件关联
@@ -362,17 +385,26 @@ func RemoveContentFile(c *gin.Context) {
 	contentID := c.Param("id")
 	fileID := c.Param("fileId")
 
+	var content Model.Content
+	if err := database.DB.First(&content, contentID).Error; err != nil {
+		constants.SendResponse(c, constants.ContentNotFound, nil)
+		return
+	}
+	if !rbac.Authorize(c, rbac.ContentUpdate, content.UserID, constants.ContentForbidden) {
+		return
+	}
+
 	result := database.DB.Where("content_id = ? AND file_id = ?", contentID, fileID).Delete(&Model.ContentFile{})
 	if result.Error != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": result.Error.Error()})
+		sendSystemError(c, result.Error)
 		return
 	}
 	if result.RowsAffected == 0 {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "未找到文件关联"})
+		constants.SendResponse(c, constants.NotFound, nil)
 		return
 	}
 
-	constants.SendResponse(c, constants.Success, gin.H{"message": "文件关联移除成功"})
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.file_unlinked")})
 }
 
 // GetContentFiles 获取内容关联的所有文件
@@ -382,9 +414,9 @@ func GetContentFiles(c *gin.Context) {
 	var contentFiles []Model.ContentFile
 	if err := database.DB.Preload("FileRecord").
 		Where("content_id = ?", contentID).
-		Order("`order` ASC").
+		Order(database.Quote("order") + " ASC").
 		Find(&contentFiles).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -1,63 +1,160 @@
 package controller
 
 import (
+	"blog/Model"
 	"blog/constants"
+	"blog/database"
+	"blog/i18n"
+	"blog/logger"
+	"blog/mail"
 	"blog/service"
+	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// sendVerificationRequest 发送验证邮件的请求体
+type sendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 // SendVerificationEmail 发送验证邮件
 func SendVerificationEmail(c *gin.Context) {
-	var req struct {
-		Email string `json:"email" binding:"required,email"`
-	}
+	var req sendVerificationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
-	service.GenerateAndStoreVerificationCode(req.Email)
+	sendVerificationCode(c, req.Email)
+}
+
+// sendVerificationCode 向邮箱发送验证码并写入响应；距上次发送不足一分钟时返回需要等待的秒数
+func sendVerificationCode(c *gin.Context, email string) {
+	err := service.SendVerificationCode(c.Request.Context(), email, i18n.FromGin(c))
+	switch {
+	case errors.Is(err, service.ErrCodeTooFrequent):
+		wait := service.ResendWait(service.PurposeVerify, email)
+		constants.SendResponse(c, constants.EmailTooFrequent, gin.H{"wait": int(wait.Round(time.Second).Seconds())})
+		return
+	case errors.Is(err, mail.ErrSendFailed):
+		logger.FromGin(c).Error("发送验证邮件失败", "error", err)
+		constants.SendResponse(c, constants.EmailSendFailed, nil)
+		return
+	case err != nil:
+		sendSystemError(c, err)
+		return
+	}
 
 	constants.SendResponse(c, constants.Success, gin.H{
-		"message":   "验证码已发送",
-		"expire_in": 300, // 5分钟有效期
+		"message":   localize(c, "message.code_sent"),
+		"expire_in": int(service.VerificationCodeTTL.Seconds()),
+		"resend_in": int(service.CodeResendInterval.Seconds()),
 	})
+}
 
-	if ttl, _ := service.GetVerificationCodeTTL(req.Email); ttl > 0 {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error": "请求过于频繁",
-			"wait":  int(ttl.Seconds()),
-		})
-		return
+// sendCodeError 验证码校验失败时写入响应，返回 false 表示 err 为 nil
+func sendCodeError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrCodeInvalid):
+		constants.SendResponse(c, constants.EmailCodeInvalid, nil)
+	case errors.Is(err, service.ErrCodeAttemptsExceeded):
+		constants.SendResponse(c, constants.EmailCodeAttemptsExceeded, nil)
+	default:
+		sendSystemError(c, err)
 	}
+	return true
+}
+
+// checkVerificationRequest 校验验证码的请求体
+type checkVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
 }
 
-// CheckVerificationCode 检查验证码
+// CheckVerificationCode 检查验证码；只做预校验，验证码仍可用于注册等后续操作
 func CheckVerificationCode(c *gin.Context) {
-	var req struct {
-		Email string `json:"email" binding:"required,email"`
-		Code  string `json:"code" binding:"required"`
-	}
+	var req checkVerificationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	if sendCodeError(c, service.CheckCode(service.PurposeVerify, req.Email, req.Code)) {
 		return
 	}
 
-	// 验证验证码
-	ok, err := service.VerifyEmailCode(req.Email, req.Code)
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message": localize(c, "message.code_verified"),
+	})
+}
+
+// loadCurrentUserEmail 读取当前用户的邮箱信息，用户未设置邮箱或已验证时写入响应并返回 nil
+func loadCurrentUserEmail(c *gin.Context) *Model.User {
+	userID, err := getUserID(c)
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": "验证码验证失败"})
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return nil
+	}
+
+	var user Model.User
+	if err := database.DB.Select("user_id", "email", "email_verified_at").First(&user, userID).Error; err != nil {
+		constants.SendResponse(c, constants.UserNotFound, nil)
+		return nil
+	}
+	if user.Email == "" {
+		constants.SendResponse(c, constants.UserEmailMissing, nil)
+		return nil
+	}
+	if user.EmailVerified() {
+		constants.SendResponse(c, constants.UserEmailVerified, nil)
+		return nil
+	}
+	return &user
+}
+
+// SendUserVerificationEmail 向当前用户的邮箱发送验证码，用于验证注册后修改的邮箱或第三方登录带来的邮箱
+func SendUserVerificationEmail(c *gin.Context) {
+	user := loadCurrentUserEmail(c)
+	if user == nil {
+		return
+	}
+	sendVerificationCode(c, user.Email)
+}
+
+// verifyUserEmailRequest 验证当前用户邮箱的请求体
+type verifyUserEmailRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// VerifyUserEmail 使用验证码验证当前用户的邮箱
+func VerifyUserEmail(c *gin.Context) {
+	var req verifyUserEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
 		return
 	}
-	if !ok {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "验证码错误或已过期"})
+
+	user := loadCurrentUserEmail(c)
+	if user == nil {
+		return
+	}
+	if sendCodeError(c, service.ConsumeCode(service.PurposeVerify, user.Email, req.Code)) {
 		return
 	}
 
+	now := time.Now()
+	if err := database.DB.Model(user).Update("email_verified_at", now).Error; err != nil {
+		sendSystemError(c, err)
+		return
+	}
 	constants.SendResponse(c, constants.Success, gin.H{
-		"message": "验证成功",
+		"message":           localize(c, "message.email_verified"),
+		"email_verified_at": now,
 	})
 }
@@ -13,11 +13,27 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"blog/lifecycle"
+	"blog/logger"
+	"blog/metrics"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// ActiveUploads 进行中的上传，服务关闭时等待其完成
+var ActiveUploads lifecycle.Tracker
+
+// beginUpload 登记一次上传，服务正在关闭时返回 503
+func beginUpload(c *gin.Context) bool {
+	if !ActiveUploads.Begin() {
+		c.Header("Connection", "close")
+		constants.SendResponse(c, constants.ShuttingDown, nil)
+		return false
+	}
+	return true
+}
+
 // GetImageStoragePath 获取图片存储目录路径
 func GetImageStoragePath() string {
 	dir, _ := os.Getwd()
@@ -35,35 +51,34 @@ func randSuffix(n int) string {
 
 // UploadFile 上传图片文件（仅支持 png/jpg/jpeg，最大 10MB）
 func uploadimg(c *gin.Context) {
+	if !beginUpload(c) {
+		return
+	}
+	defer ActiveUploads.Done()
+
+	result := "failed"
+	defer func() { metrics.Uploads.WithLabelValues("image", result).Inc() }()
+
 	const maxSize = 10 << 20 // 10MB
 	allowedExt := map[string]bool{".png": true, ".jpg": true, ".jpeg": true}
 
 	// 获取上传文件
 	fh, err := c.FormFile("file")
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error": "file is required",
-			"hint":  "use form-data with field name 'file'",
-		})
+		constants.SendResponse(c, constants.FileMissing, nil)
 		return
 	}
 
 	// 校验文件大小
 	if fh.Size > maxSize {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error":     "file too large",
-			"max_bytes": maxSize,
-		})
+		constants.SendResponse(c, constants.FileTooLarge, gin.H{"max_bytes": maxSize})
 		return
 	}
 
 	// 校验文件扩展名
 	ext := strings.ToLower(filepath.Ext(fh.Filename))
 	if !allowedExt[ext] {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error":   "invalid file type",
-			"allowed": []string{"png", "jpg", "jpeg"},
-		})
+		constants.SendResponse(c, constants.FileTypeNotAllowed, gin.H{"allowed": []string{"png", "jpg", "jpeg"}})
 		return
 	}
 
@@ -73,10 +88,8 @@ func uploadimg(c *gin.Context) {
 
 	// 保存文件到磁盘
 	if err := c.SaveUploadedFile(fh, dst); err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "save file failed",
-			"detail": err.Error(),
-		})
+		logger.FromGin(c).Error("保存上传文件失败", "error", err)
+		constants.SendResponse(c, constants.FileUploadFailed, nil)
 		return
 	}
 
@@ -115,7 +128,7 @@ func uploadimg(c *gin.Context) {
 			var maxOrder int
 			tx.Model(&Model.ContentFile{}).
 				Where("content_id = ?", contentID).
-				Select("COALESCE(MAX(`order`), -1)").
+				Select("COALESCE(MAX(" + database.Quote("order") + "), -1)").
 				Scan(&maxOrder)
 
 			// 创建文章-文件关联
@@ -135,12 +148,12 @@ func uploadimg(c *gin.Context) {
 
 	if err != nil {
 		_ = os.Remove(dst) // 删除已保存的文件
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "store record failed",
-			"detail": err.Error(),
-		})
+		logger.FromGin(c).Error("保存文件记录失败", "error", err)
+		constants.SendResponse(c, constants.FileUploadFailed, nil)
 		return
 	}
+	result = "success"
+	metrics.UploadBytes.WithLabelValues("image").Add(float64(fh.Size))
 	constants.SendResponse(c, constants.Success, fr)
 }
 
@@ -150,10 +163,7 @@ func ListImages(c *gin.Context) {
 
 	// 从数据库查询所有图片记录
 	if err := database.DB.Where("status = ?", "active").Find(&records).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "query images failed",
-			"detail": err.Error(),
-		})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -170,12 +180,9 @@ func GetContentImages(c *gin.Context) {
 	var contentFiles []Model.ContentFile
 	if err := database.DB.Preload("FileRecord").
 		Where("content_id = ?", contentID).
-		Order("`order` ASC, created_at ASC").
+		Order(database.Quote("order") + " ASC, created_at ASC").
 		Find(&contentFiles).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "query content images failed",
-			"detail": err.Error(),
-		})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -194,6 +201,14 @@ func GetContentImages(c *gin.Context) {
 
 // UploadFile 上传通用文件
 func UploadFile(c *gin.Context) {
+	if !beginUpload(c) {
+		return
+	}
+	defer ActiveUploads.Done()
+
+	result := "failed"
+	defer func() { metrics.Uploads.WithLabelValues("file", result).Inc() }()
+
 	const maxSize = 50 << 20 // 50MB
 	// 允许的扩展名
 	allowedExt := map[string]bool{
@@ -206,28 +221,20 @@ func UploadFile(c *gin.Context) {
 	// 获取上传文件
 	fh, err := c.FormFile("file")
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error": "file is required",
-			"hint":  "use form-data with field name 'file'",
-		})
+		constants.SendResponse(c, constants.FileMissing, nil)
 		return
 	}
 
 	// 校验文件大小
 	if fh.Size > maxSize {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error":     "file too large",
-			"max_bytes": maxSize,
-		})
+		constants.SendResponse(c, constants.FileTooLarge, gin.H{"max_bytes": maxSize})
 		return
 	}
 
 	// 校验文件扩展名
 	ext := strings.ToLower(filepath.Ext(fh.Filename))
 	if !allowedExt[ext] {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error": "file type not allowed",
-		})
+		constants.SendResponse(c, constants.FileTypeNotAllowed, nil)
 		return
 	}
 
@@ -237,10 +244,8 @@ func UploadFile(c *gin.Context) {
 
 	// 保存文件到磁盘
 	if err := c.SaveUploadedFile(fh, dst); err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "save file failed",
-			"detail": err.Error(),
-		})
+		logger.FromGin(c).Error("保存上传文件失败", "error", err)
+		constants.SendResponse(c, constants.FileUploadFailed, nil)
 		return
 	}
 
@@ -279,7 +284,7 @@ func UploadFile(c *gin.Context) {
 			var maxOrder int
 			tx.Model(&Model.ContentFile{}).
 				Where("content_id = ?", contentID).
-				Select("COALESCE(MAX(`order`), -1)").
+				Select("COALESCE(MAX(" + database.Quote("order") + "), -1)").
 				Scan(&maxOrder)
 
 			// 创建文章-文件关联
@@ -299,11 +304,11 @@ func UploadFile(c *gin.Context) {
 
 	if err != nil {
 		_ = os.Remove(dst) // 删除已保存的文件
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error":  "store record failed",
-			"detail": err.Error(),
-		})
+		logger.FromGin(c).Error("保存文件记录失败", "error", err)
+		constants.SendResponse(c, constants.FileUploadFailed, nil)
 		return
 	}
+	result = "success"
+	metrics.UploadBytes.WithLabelValues("file").Add(float64(fh.Size))
 	constants.SendResponse(c, constants.Success, fr)
 }
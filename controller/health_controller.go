@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"blog/constants"
+	"blog/database"
+	"blog/lifecycle"
+	"blog/logger"
+	"blog/migrations"
+	"blog/version"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz 存活检查：进程能处理请求即返回 200
+// GET /healthz
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪检查：数据库和 KV 存储（Redis）都可用时返回 200，否则返回 503；
+// 进入关闭流程后直接返回 503，让负载均衡尽快摘除本实例。
+// 响应只包含各依赖的状态，错误详情只写入日志，避免向未认证的调用方暴露内部地址等信息
+// GET /readyz
+func Readyz(c *gin.Context) {
+	if lifecycle.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := gin.H{
+		"database": checkResult(c, "database", pingDB(ctx)),
+		"kv":       checkResult(c, "kv", pingKV(ctx)),
+	}
+
+	status, code := "ok", http.StatusOK
+	for _, v := range checks {
+		if v != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// DebugInfo 诊断信息（需要 system.debug 权限）：版本、运行时长、迁移版本、连接池状态
+// GET /debug/info
+func DebugInfo(c *gin.Context) {
+	info := gin.H{
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_time": version.BuildTime,
+		"go_version": runtime.Version(),
+		"started_at": version.StartTime,
+		"uptime":     version.Uptime().Round(time.Second).String(),
+		"goroutines": runtime.NumGoroutine(),
+	}
+
+	if database.DB != nil {
+		dbInfo := gin.H{"dialect": database.DB.Dialector.Name()}
+		if v, err := migrations.CurrentVersion(database.DB); err == nil {
+			info["migration_version"] = v
+		} else {
+			info["migration_version"] = err.Error()
+		}
+		if sqlDB, err := database.DB.DB(); err == nil {
+			stats := sqlDB.Stats()
+			dbInfo["pool"] = gin.H{
+				"max_open_connections": stats.MaxOpenConnections,
+				"open_connections":     stats.OpenConnections,
+				"in_use":               stats.InUse,
+				"idle":                 stats.Idle,
+				"wait_count":           stats.WaitCount,
+				"wait_duration":        stats.WaitDuration.String(),
+				"max_idle_closed":      stats.MaxIdleClosed,
+				"max_lifetime_closed":  stats.MaxLifetimeClosed,
+			}
+		}
+		info["database"] = dbInfo
+	}
+
+	if database.Store != nil {
+		info["kv_driver"] = database.Store.Driver()
+	}
+
+	if database.RedisClient != nil {
+		stats := database.RedisClient.PoolStats()
+		info["redis"] = gin.H{
+			"addr": database.RedisClient.Options().Addr,
+			"pool": gin.H{
+				"hits":        stats.Hits,
+				"misses":      stats.Misses,
+				"timeouts":    stats.Timeouts,
+				"total_conns": stats.TotalConns,
+				"idle_conns":  stats.IdleConns,
+				"stale_conns": stats.StaleConns,
+			},
+		}
+	}
+
+	constants.SendResponse(c, constants.Success, info)
+}
+
+// pingDB 检查数据库连接
+func pingDB(ctx context.Context) error {
+	if database.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// pingKV 检查键值存储（Redis 或内存）
+func pingKV(ctx context.Context) error {
+	if database.Store == nil {
+		return fmt.Errorf("KV存储未初始化")
+	}
+	return database.Store.Ping(ctx)
+}
+
+// checkResult 将检查结果转为状态字符串：成功为 "ok"，失败为 "unavailable" 并记录错误
+func checkResult(c *gin.Context, name string, err error) string {
+	if err != nil {
+		logger.FromGin(c).Warn("就绪检查失败", "dependency", name, "error", err)
+		return "unavailable"
+	}
+	return "ok"
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"blog/Model"
+	"blog/constants"
+	"blog/database"
+	"blog/logger"
+	"blog/metrics"
+	"blog/service"
+	"blog/session"
+	"blog/utils"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendMFAError 两步验证相关的错误写入响应
+func sendMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFACodeInvalid):
+		constants.SendResponse(c, constants.MFACodeInvalid, nil)
+	case errors.Is(err, service.ErrMFAChallengeInvalid):
+		constants.SendResponse(c, constants.MFAChallengeInvalid, nil)
+	case errors.Is(err, service.ErrMFATooManyAttempts):
+		constants.SendResponse(c, constants.MFATooManyAttempts, nil)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		constants.SendResponse(c, constants.MFAAlreadyEnabled, nil)
+	case errors.Is(err, service.ErrMFANotEnabled):
+		constants.SendResponse(c, constants.MFANotEnabled, nil)
+	case errors.Is(err, service.ErrMFASetupExpired):
+		constants.SendResponse(c, constants.MFASetupExpired, nil)
+	case errors.Is(err, service.ErrUserBanned):
+		constants.SendResponse(c, constants.AuthUserBanned, nil)
+	default:
+		sendSystemError(c, err)
+	}
+}
+
+// loadCurrentUser 读取当前登录用户，失败时写入响应并返回 nil
+func loadCurrentUser(c *gin.Context) *Model.User {
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return nil
+	}
+	var user Model.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		constants.SendResponse(c, constants.UserNotFound, nil)
+		return nil
+	}
+	return &user
+}
+
+// loginMFARequest 登录第二步的请求体，code 为验证器 App 中的 6 位验证码或恢复码
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// LoginMFA 登录第二步：提交两步验证码，成功后签发令牌对
+func LoginMFA(c *gin.Context) {
+	var req loginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	user, device, err := service.CompleteMFAChallenge(req.MFAToken, req.Code)
+	if err != nil {
+		metrics.Logins.WithLabelValues("mfa", "failed").Inc()
+		sendMFAError(c, err)
+		return
+	}
+
+	tokens, err := service.IssueTokenPair(user.UserID, user.Username, sessionClient(c, device))
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+
+	metrics.Logins.WithLabelValues("mfa", "success").Inc()
+	user.Password = "" // 清除密码
+	constants.SendResponse(c, constants.Success, loginResponse{User: *user, TokenPair: *tokens})
+}
+
+// mfaStatus 两步验证状态
+type mfaStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+	Required               bool       `json:"required"` // 是否被要求必须启用（管理员）
+}
+
+// GetMFAStatus 当前用户的两步验证状态
+func GetMFAStatus(c *gin.Context) {
+	user := loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+
+	status := mfaStatus{
+		Enabled:   user.MFAEnabled(),
+		EnabledAt: user.MFAEnabledAt,
+		Required:  service.MFARequired(user),
+	}
+	if status.Enabled {
+		remaining, err := service.RemainingRecoveryCodes(user.UserID)
+		if err != nil {
+			sendSystemError(c, err)
+			return
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+	constants.SendResponse(c, constants.Success, status)
+}
+
+// SetupTOTP 开始绑定验证器 App：返回密钥、otpauth 链接和二维码
+func SetupTOTP(c *gin.Context) {
+	user := loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+
+	setup, err := service.BeginTOTPSetup(user)
+	if err != nil {
+		sendMFAError(c, err)
+		return
+	}
+	constants.SendResponse(c, constants.Success, setup)
+}
+
+// mfaCodeRequest 提交两步验证码的请求体
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// recoveryCodesData 新生成的恢复码，只在生成时返回一次
+type recoveryCodesData struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnableTOTP 提交验证器 App 中的验证码完成绑定，启用后注销其他设备上的登录
+func EnableTOTP(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return
+	}
+
+	codes, err := service.EnableTOTP(userID, req.Code)
+	if err != nil {
+		sendMFAError(c, err)
+		return
+	}
+	if _, err := session.RevokeAll(userID, currentSessionID(c)); err != nil {
+		logger.FromGin(c).Error("启用两步验证后注销其他会话失败", "error", err)
+	}
+
+	constants.SendResponse(c, constants.Success, recoveryCodesData{
+		Message:       localize(c, "message.mfa_enabled"),
+		RecoveryCodes: codes,
+	})
+}
+
+// disableMFARequest 关闭两步验证的请求体；通过第三方登录注册、没有设置密码的用户可以不传密码
+type disableMFARequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// DisableMFA 关闭两步验证，需要同时提供密码和验证码（或恢复码）
+func DisableMFA(c *gin.Context) {
+	var req disableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+	user := loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+
+	if service.MFARequired(user) {
+		constants.SendResponse(c, constants.MFARequired, nil)
+		return
+	}
+	if user.Password != "" && !utils.CheckPassword(req.Password, user.Password) {
+		constants.SendResponse(c, constants.UserPasswordIncorrect, nil)
+		return
+	}
+	if err := service.VerifyMFACodeLimited(user, req.Code); err != nil {
+		sendMFAError(c, err)
+		return
+	}
+	if err := service.DisableMFA(user.UserID); err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.mfa_disabled")})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+	user := loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+
+	if err := service.VerifyMFACodeLimited(user, req.Code); err != nil {
+		sendMFAError(c, err)
+		return
+	}
+	codes, err := service.RegenerateRecoveryCodes(user.UserID)
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	constants.SendResponse(c, constants.Success, recoveryCodesData{
+		Message:       localize(c, "message.recovery_codes_regenerated"),
+		RecoveryCodes: codes,
+	})
+}
@@ -4,29 +4,32 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"blog/logger"
+	"blog/metrics"
 	"blog/service"
-	"blog/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// oauthService 全局OAuth服务实例
-var oauthService = service.NewOAuthService()
+// oauthService 全局OAuth服务实例，在 InitRoutes 中创建（此时数据库已连接）
+var oauthService *service.OAuthService
 
 func GetOAuthPlatforms(c *gin.Context) {
 	platforms, err := oauthService.GetEnabledPlatforms()
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "获取平台列表失败"})
+		sendSystemError(c, err)
 		return
 	}
 
-	constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{
+	constants.SendResponse(c, constants.Success, gin.H{
 		"platforms": platforms,
 	})
 }
@@ -38,7 +41,7 @@ func OAuthLogin(c *gin.Context) {
 	// 1. 根据平台名获取平台配置
 	platform, err := oauthService.GetPlatformByName(platformName)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthNotFound, gin.H{"error": "不支持的OAuth平台"})
+		constants.SendResponse(c, constants.OAuthPlatformNotFound, nil)
 		return
 	}
 
@@ -50,7 +53,7 @@ func OAuthLogin(c *gin.Context) {
 
 	// 4. 保存state到数据库（10分钟有效期）
 	if err := oauthService.SaveState(state, 0, platform.OAuthID, 10*time.Minute); err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "生成认证状态失败"})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -58,9 +61,8 @@ func OAuthLogin(c *gin.Context) {
 	authURL := oauthConfig.AuthCodeURL(state)
 
 	// 返回授权URL让前端跳转（或服务端直接重定向）
-	c.JSON(http.StatusOK, gin.H{
-		"code":     200,
-		"message":  "请跳转到授权URL",
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message":  localize(c, "message.oauth_redirect"),
 		"auth_url": authURL,
 	})
 }
@@ -82,7 +84,7 @@ func OAuthCallback(c *gin.Context) {
 	// 如果GitHub返回了错误（用户拒绝授权等）
 	if callbackError != "" {
 		errorDesc := c.Query("error_description")
-		constants.SendOAuthResponse(c, constants.OAuthCallbackError, gin.H{
+		constants.SendResponse(c, constants.OAuthCallbackFailed, gin.H{
 			"error":       callbackError,
 			"description": errorDesc,
 		})
@@ -90,21 +92,21 @@ func OAuthCallback(c *gin.Context) {
 	}
 
 	if code == "" || state == "" {
-		constants.SendOAuthResponse(c, constants.OAuthBadRequest, gin.H{"error": "缺少code或state参数"})
+		constants.SendResponse(c, constants.OAuthStateInvalid, nil)
 		return
 	}
 
 	// 2. 验证state（防止CSRF）
 	oauthState, err := oauthService.VerifyState(state)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthUnauthorized, gin.H{"error": "无效的state，可能已过期或被篡改"})
+		constants.SendResponse(c, constants.OAuthStateInvalid, nil)
 		return
 	}
 
 	// 3. 获取平台配置
 	platform, err := oauthService.GetPlatformByName(platformName)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthNotFound, gin.H{"error": "不支持的OAuth平台"})
+		constants.SendResponse(c, constants.OAuthPlatformNotFound, nil)
 		return
 	}
 
@@ -114,21 +116,24 @@ func OAuthCallback(c *gin.Context) {
 	// 5. 用code换取access_token
 	token, err := oauthConfig.Exchange(context.Background(), code)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthCallbackError, gin.H{"error": "获取access_token失败: " + err.Error()})
+		metrics.Logins.WithLabelValues("oauth", "failed").Inc()
+		logger.FromGin(c).Warn("获取access_token失败", "error", err)
+		constants.SendResponse(c, constants.OAuthCallbackFailed, nil)
 		return
 	}
 
 	// 6. 使用access_token获取用户信息
 	userInfo, err := fetchGitHubUserInfo(token.AccessToken)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthCallbackError, gin.H{"error": "获取用户信息失败: " + err.Error()})
+		logger.FromGin(c).Warn("获取第三方用户信息失败", "error", err)
+		constants.SendResponse(c, constants.OAuthCallbackFailed, nil)
 		return
 	}
 
 	// 7. 提取平台用户ID
 	platformUserID := extractPlatformUserID(platformName, userInfo)
 	if platformUserID == "" {
-		constants.SendOAuthResponse(c, constants.OAuthCallbackError, gin.H{"error": "无法获取第三方平台用户ID"})
+		constants.SendResponse(c, constants.OAuthCallbackFailed, nil)
 		return
 	}
 
@@ -136,11 +141,15 @@ func OAuthCallback(c *gin.Context) {
 	if oauthState.UserID > 0 {
 		// 已登录用户绑定第三方账号
 		err = oauthService.BindOAuthAccount(oauthState.UserID, platform.OAuthID, platformUserID, userInfo, token)
+		if errors.Is(err, service.ErrOAuthAccountBound) {
+			constants.SendResponse(c, constants.OAuthAccountBound, nil)
+			return
+		}
 		if err != nil {
-			constants.SendOAuthResponse(c, constants.OAuthConflict, gin.H{"error": err.Error()})
+			sendSystemError(c, err)
 			return
 		}
-		constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{"message": "第三方账号绑定成功"})
+		constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.oauth_bound")})
 		return
 	}
 
@@ -149,38 +158,31 @@ func OAuthCallback(c *gin.Context) {
 	if err != nil {
 		// 用户不存在，自动创建新用户
 		user, err = oauthService.CreateOrUpdateUser(platform, userInfo, platformUserID)
+		if errors.Is(err, service.ErrOAuthEmailConflict) {
+			metrics.Logins.WithLabelValues("oauth", "failed").Inc()
+			constants.SendResponse(c, constants.OAuthEmailConflict, nil)
+			return
+		}
 		if err != nil {
-			constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "创建用户失败: " + err.Error()})
+			sendSystemError(c, err)
 			return
 		}
 
 		// 绑定OAuth账号到新用户
 		if err := oauthService.BindOAuthAccount(user.UserID, platform.OAuthID, platformUserID, userInfo, token); err != nil {
-			constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "绑定账号失败: " + err.Error()})
+			sendSystemError(c, err)
 			return
 		}
 	}
 
-	// 9. 为用户生成JWT Token
-	jwtToken, err := utils.GenerateToken(int64(user.UserID), user.Username)
-	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "生成token失败"})
-		return
-	}
-
-	// 10. 存储token到Redis
-	tokenKey := fmt.Sprintf("user_token:%d", user.UserID)
-	if err := database.SetString(tokenKey, jwtToken, 24*time.Hour); err != nil {
-		fmt.Printf("Redis SetString Error: %v\n", err)
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "存储token失败"})
+	if user.IsBanned() {
+		metrics.Logins.WithLabelValues("oauth", "failed").Inc()
+		constants.SendResponse(c, constants.AuthUserBanned, nil)
 		return
 	}
 
-	user.Password = "" // 清除密码
-	constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{
-		"user":  user,
-		"token": jwtToken,
-	})
+	// 9. 与密码登录相同：启用了两步验证的用户需要再提交验证码，否则直接签发令牌对
+	completeLogin(c, user, "", "oauth")
 }
 
 // ============================================================
@@ -195,7 +197,7 @@ func OAuthBind(c *gin.Context) {
 	// 获取当前登录用户ID
 	currentUserIDVal, exists := c.Get("user_id")
 	if !exists {
-		constants.SendOAuthResponse(c, constants.OAuthUnauthorized, gin.H{"error": "请先登录"})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	currentUserID := uint(currentUserIDVal.(int64))
@@ -203,7 +205,7 @@ func OAuthBind(c *gin.Context) {
 	// 获取平台配置
 	platform, err := oauthService.GetPlatformByName(platformName)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthNotFound, gin.H{"error": "不支持的OAuth平台"})
+		constants.SendResponse(c, constants.OAuthPlatformNotFound, nil)
 		return
 	}
 
@@ -213,14 +215,13 @@ func OAuthBind(c *gin.Context) {
 	// 生成state（带上用户ID，回调时用于绑定）
 	state := database.GenerateMixedCode(32)
 	if err := oauthService.SaveState(state, currentUserID, platform.OAuthID, 10*time.Minute); err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "生成认证状态失败"})
+		sendSystemError(c, err)
 		return
 	}
 
 	authURL := oauthConfig.AuthCodeURL(state)
-	c.JSON(http.StatusOK, gin.H{
-		"code":     200,
-		"message":  "请跳转到授权URL完成绑定",
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message":  localize(c, "message.oauth_bind_redirect"),
 		"auth_url": authURL,
 	})
 }
@@ -237,7 +238,7 @@ func OAuthUnbind(c *gin.Context) {
 	// 获取当前登录用户ID
 	currentUserIDVal, exists := c.Get("user_id")
 	if !exists {
-		constants.SendOAuthResponse(c, constants.OAuthUnauthorized, gin.H{"error": "请先登录"})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	currentUserID := uint(currentUserIDVal.(int64))
@@ -245,17 +246,24 @@ func OAuthUnbind(c *gin.Context) {
 	// 获取平台
 	platform, err := oauthService.GetPlatformByName(platformName)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthNotFound, gin.H{"error": "不支持的OAuth平台"})
+		constants.SendResponse(c, constants.OAuthPlatformNotFound, nil)
 		return
 	}
 
 	// 解绑
 	if err := oauthService.UnbindOAuthAccount(currentUserID, platform.OAuthID); err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthBadRequest, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrOAuthLastLoginMethod):
+			constants.SendResponse(c, constants.OAuthLastLoginMethod, nil)
+		case errors.Is(err, service.ErrOAuthBindingNotFound):
+			constants.SendResponse(c, constants.OAuthAccountNotBound, nil)
+		default:
+			sendSystemError(c, err)
+		}
 		return
 	}
 
-	constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{"message": "解绑成功"})
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.oauth_unbound")})
 }
 
 // ============================================================
@@ -267,18 +275,18 @@ func OAuthUnbind(c *gin.Context) {
 func GetUserOAuthAccounts(c *gin.Context) {
 	currentUserIDVal, exists := c.Get("user_id")
 	if !exists {
-		constants.SendOAuthResponse(c, constants.OAuthUnauthorized, gin.H{"error": "请先登录"})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	currentUserID := uint(currentUserIDVal.(int64))
 
 	accounts, err := oauthService.GetUserOAuthAccounts(currentUserID)
 	if err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "获取绑定列表失败"})
+		sendSystemError(c, err)
 		return
 	}
 
-	constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{
+	constants.SendResponse(c, constants.Success, gin.H{
 		"accounts": accounts,
 	})
 }
@@ -287,70 +295,44 @@ func GetUserOAuthAccounts(c *gin.Context) {
 // 7. 初始化OAuth平台配置（管理员接口 / 首次部署时使用）
 // ============================================================
 
-// InitGitHubPlatform 初始化GitHub OAuth平台配置
+// initPlatformRequest 初始化 OAuth 平台的请求体
+type initPlatformRequest struct {
+	ClientID     string `json:"client_id" binding:"required"`
+	ClientSecret string `json:"client_secret" binding:"required"`
+	RedirectURL  string `json:"redirect_url" binding:"required"`
+}
+
+// InitGitHubPlatform 初始化GitHub OAuth平台配置（需要 oauth.manage 权限）
 // POST /oauth/admin/init-github
 func InitGitHubPlatform(c *gin.Context) {
-	// 检查管理员权限
-	currentUserIDVal, exists := c.Get("user_id")
-	if !exists {
-		constants.SendOAuthResponse(c, constants.OAuthUnauthorized, nil)
-		return
-	}
-	if !checkIsAdmin(uint(currentUserIDVal.(int64))) {
-		constants.SendOAuthResponse(c, constants.OAuthForbidden, gin.H{"error": "需要管理员权限"})
-		return
-	}
-
-	var req struct {
-		ClientID     string `json:"client_id" binding:"required"`
-		ClientSecret string `json:"client_secret" binding:"required"`
-		RedirectURL  string `json:"redirect_url" binding:"required"`
-	}
+	var req initPlatformRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthBadRequest, gin.H{"error": "参数错误"})
-		return
-	}
-
-	// 检查是否已存在
-	var count int64
-	database.DB.Model(&Model.OAuthPlatform{}).Where("platform = ?", "github").Count(&count)
-	if count > 0 {
-		// 更新已有配置
-		if err := database.DB.Model(&Model.OAuthPlatform{}).Where("platform = ?", "github").Updates(map[string]interface{}{
-			"client_id":     req.ClientID,
-			"client_secret": req.ClientSecret,
-			"redirect_url":  req.RedirectURL,
-		}).Error; err != nil {
-			constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "更新平台配置失败"})
-			return
-		}
-		constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{"message": "GitHub OAuth配置已更新"})
+		constants.SendValidationError(c, err)
 		return
 	}
 
-	// 创建新配置
 	platform := Model.OAuthPlatform{
 		Platform:     "github",
 		DisplayName:  "GitHub",
 		ClientID:     req.ClientID,
 		ClientSecret: req.ClientSecret,
 		RedirectURL:  req.RedirectURL,
-		AuthURL:      "https://github.com/login/oauth/authorize",
-		TokenURL:     "https://github.com/login/oauth/access_token",
-		UserInfoURL:  "https://api.github.com/user",
-		Scopes:       "user:email",
 		IconURL:      "https://github.githubassets.com/favicons/favicon.svg",
 		SortOrder:    1,
-		IsEnabled:    true,
 	}
 
-	if err := database.DB.Create(&platform).Error; err != nil {
-		constants.SendOAuthResponse(c, constants.OAuthSystemError, gin.H{"error": "创建平台配置失败"})
+	created, err := oauthService.SavePlatform(&platform)
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	if !created {
+		constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.platform_updated")})
 		return
 	}
 
-	constants.SendOAuthResponse(c, constants.OAuthSuccess, gin.H{
-		"message":  "GitHub OAuth平台配置成功",
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message":  localize(c, "message.platform_created"),
 		"platform": platform,
 	})
 }
@@ -395,23 +377,31 @@ func fetchGitHubUserInfo(accessToken string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("解析用户信息失败: %v", err)
 	}
 
-	// 额外获取用户邮箱（GitHub用户可能设置邮箱为私有）
+	// 公开资料中的邮箱可能为空（设为私有），也不一定经过验证；
+	// 以 /user/emails 中已验证的邮箱为准，email_verified 决定能否与本站已有账号自动关联
 	email, _ := userInfo["email"].(string)
-	if email == "" {
-		emailInfo, err := fetchGitHubUserEmails(accessToken)
-		if err == nil && emailInfo != "" {
-			userInfo["email"] = emailInfo
+	// 获取失败时视为没有已验证的邮箱（不会自动关联已有账号）
+	verified, _ := fetchGitHubVerifiedEmails(accessToken)
+	if email == "" && len(verified) > 0 {
+		email = verified[0]
+		userInfo["email"] = email
+	}
+	userInfo["email_verified"] = false
+	for _, v := range verified {
+		if strings.EqualFold(v, email) {
+			userInfo["email_verified"] = true
+			break
 		}
 	}
 
 	return userInfo, nil
 }
 
-// fetchGitHubUserEmails 获取GitHub用户的邮箱列表
-func fetchGitHubUserEmails(accessToken string) (string, error) {
+// fetchGitHubVerifiedEmails 获取GitHub用户已验证的邮箱，主邮箱排在最前
+func fetchGitHubVerifiedEmails(accessToken string) ([]string, error) {
 	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
@@ -420,17 +410,17 @@ func fetchGitHubUserEmails(accessToken string) (string, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取邮箱列表失败: %d", resp.StatusCode)
+		return nil, fmt.Errorf("获取邮箱列表失败: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var emails []struct {
@@ -440,24 +430,21 @@ func fetchGitHubUserEmails(accessToken string) (string, error) {
 	}
 
 	if err := json.Unmarshal(body, &emails); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// 优先返回主邮箱
+	var verified []string
 	for _, e := range emails {
-		if e.Primary && e.Verified {
-			return e.Email, nil
+		if !e.Verified {
+			continue
 		}
-	}
-
-	// 返回第一个已验证的邮箱
-	for _, e := range emails {
-		if e.Verified {
-			return e.Email, nil
+		if e.Primary {
+			verified = append([]string{e.Email}, verified...)
+		} else {
+			verified = append(verified, e.Email)
 		}
 	}
-
-	return "", nil
+	return verified, nil
 }
 
 // extractPlatformUserID 从用户信息中提取平台用户ID
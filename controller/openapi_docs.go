@@ -0,0 +1,296 @@
+package controller
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/constants"
+	"blog/i18n"
+	"blog/openapi"
+	"blog/service"
+	"blog/version"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 以下结构体只用于描述 gin.H 形式的响应，便于生成 OpenAPI 文档
+
+type messageData struct {
+	Message string `json:"message"`
+}
+
+type errorData struct {
+	Error string `json:"error"`
+}
+
+type sessionsRevoked struct {
+	Revoked int    `json:"revoked"`
+	Message string `json:"message"`
+}
+
+type userBanned struct {
+	User    Model.User `json:"user"`
+	Message string     `json:"message"`
+}
+
+type userPage struct {
+	List     []Model.User `json:"list"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+type contentPage struct {
+	List     []Model.Content `json:"list"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+type tagWithCount struct {
+	Model.Tag
+	ArticleCount int64 `json:"article_count"`
+}
+
+type imageList struct {
+	Total  int                `json:"total"`
+	Images []Model.FileRecord `json:"images"`
+}
+
+type contentImageList struct {
+	ContentID string             `json:"content_id"`
+	Total     int                `json:"total"`
+	Images    []Model.FileRecord `json:"images"`
+}
+
+type verificationSent struct {
+	Message  string `json:"message"`
+	ExpireIn int    `json:"expire_in"`
+	ResendIn int    `json:"resend_in"`
+}
+
+type emailVerified struct {
+	Message         string    `json:"message"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+}
+
+type platformList struct {
+	Platforms []Model.OAuthPlatform `json:"platforms"`
+}
+
+type authURLData struct {
+	Message string `json:"message"`
+	AuthURL string `json:"auth_url"`
+}
+
+type accountList struct {
+	Accounts []Model.OAuthAccount `json:"accounts"`
+}
+
+type platformInitData struct {
+	Message  string              `json:"message"`
+	Platform Model.OAuthPlatform `json:"platform"`
+}
+
+// 分页查询参数
+var pageQuery = []openapi.Parameter{
+	{Name: "page", In: "query", Description: "页码，从 1 开始", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "page_size", In: "query", Description: "每页数量，默认 10", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+// 上传接口的表单字段
+func uploadForm(usage string) []openapi.FormField {
+	return []openapi.FormField{
+		{Name: "file", File: true, Required: true, Description: "上传的文件"},
+		{Name: "content_id", Description: "关联的文章ID（可选）"},
+		{Name: "usage", Description: "用途，默认 " + usage},
+	}
+}
+
+// rawJSON 不使用统一响应结构的 JSON 响应
+func rawJSON(desc string) *openapi.Response {
+	return &openapi.Response{
+		Description: desc,
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: &openapi.Schema{Type: "object", Description: "不使用统一响应结构"}},
+		},
+	}
+}
+
+// apiDocs 路由文档表，新增路由时在此补充对应条目。版本化接口使用去掉 /api/<version> 前缀后的路径；
+// 未登记的路由仍会出现在文档中，但只有最基础的信息
+func apiDocs() []openapi.Route {
+	return []openapi.Route{
+		// 运维
+		{Method: http.MethodGet, Path: "/healthz", Tag: "ops", Summary: "存活检查", Raw: rawJSON("进程存活")},
+		{Method: http.MethodGet, Path: "/readyz", Tag: "ops", Summary: "就绪检查", Description: "数据库和 KV 存储都可用时返回 200，否则返回 503；服务关闭期间返回 503，status 为 draining", Raw: rawJSON("依赖检查结果")},
+		{Method: http.MethodGet, Path: config.Cfg.Metrics.Path, Tag: "ops", Summary: "Prometheus 指标", Raw: &openapi.Response{
+			Description: "Prometheus 文本格式",
+			Content:     map[string]*openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}},
+		}},
+		{Method: http.MethodGet, Path: "/debug/info", Tag: "ops", Summary: "诊断信息", Description: "需要 system.debug 权限", Auth: true, Data: map[string]interface{}{}},
+		{Method: http.MethodGet, Path: "/openapi.json", Tag: "ops", Summary: "OpenAPI 文档", Raw: rawJSON("OpenAPI 3 文档")},
+		{Method: http.MethodGet, Path: "/docs", Tag: "ops", Summary: "Swagger UI", Raw: &openapi.Response{
+			Description: "HTML 页面",
+			Content:     map[string]*openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}},
+		}},
+
+		// 文件
+		{Method: http.MethodGet, Path: "/file/listimg", Tag: "file", Summary: "获取所有图片", Data: imageList{}},
+		{Method: http.MethodGet, Path: "/file/content/:id", Tag: "file", Summary: "获取文章关联的图片", Data: contentImageList{}},
+		{Method: http.MethodPost, Path: "/file/uploadimg", Tag: "file", Summary: "上传图片", Description: "需要 file.upload 权限", Auth: true, Form: uploadForm("content"), Data: Model.FileRecord{}},
+		{Method: http.MethodPost, Path: "/file/uploadfile", Tag: "file", Summary: "上传文件", Description: "需要 file.upload 权限", Auth: true, Form: uploadForm("attachment"), Data: Model.FileRecord{}},
+		{Method: http.MethodGet, Path: "/img/*filepath", Tag: "file", Summary: "访问已上传的图片", Raw: &openapi.Response{
+			Description: "文件内容",
+			Content:     map[string]*openapi.MediaType{"application/octet-stream": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+		}},
+
+		// 用户
+		{Method: http.MethodPost, Path: "/user/register", Tag: "user", Summary: "注册", Description: "先调用 /email/verify 获取验证码，注册时一并提交；验证码在注册成功后失效。用户名和密码不符合规则时返回 request.validation_failed，errors 中列出全部违反的规则", Body: registerRequest{}, Data: Model.User{}},
+		{Method: http.MethodPost, Path: "/user/login", Tag: "user", Summary: "登录", Description: "启用了两步验证的用户返回 mfa_required、mfa_token（不含令牌对），需再调用 /user/login/mfa；连续失败后需要等待（auth.login_throttled，data.wait 为秒数），失败过多时账号被临时锁定（auth.account_locked）并向邮箱发送解锁链接；两步验证码错误过多时返回 mfa.too_many_attempts", Body: Model.LoginRequest{}, Data: loginResponse{}},
+		{Method: http.MethodPost, Path: "/user/login/mfa", Tag: "user", Summary: "两步验证登录", Description: "提交验证器 App 中的 6 位验证码或恢复码；mfa_token 5 分钟内有效；同一用户 15 分钟内错误 5 次后返回 mfa.too_many_attempts，窗口结束前重新登录也不会签发新的 mfa_token", Body: loginMFARequest{}, Data: loginResponse{}},
+		{Method: http.MethodPost, Path: "/user/token/refresh", Tag: "user", Summary: "刷新令牌", Description: "refresh token 只能使用一次，成功后返回新的令牌对；旧 token 被重复使用时该登录的所有令牌立即失效", Body: refreshTokenRequest{}, Data: service.TokenPair{}},
+		{Method: http.MethodPost, Path: "/user/logout", Tag: "user", Summary: "登出", Description: "吊销当前 access token 并注销当前设备的会话，refresh token 随之失效", Auth: true, Data: messageData{}},
+		{Method: http.MethodPut, Path: "/user/password", Tag: "user", Summary: "修改密码", Description: "新密码需符合密码规则，不符合时 errors 中列出全部违反的规则；成功后注销包括当前设备在内的全部登录，需要重新登录", Auth: true, Body: changePasswordRequest{}, Data: messageData{}},
+		{Method: http.MethodPost, Path: "/user/password/forgot", Tag: "user", Summary: "找回密码", Description: "向注册邮箱发送验证码，15 分钟内有效；无论邮箱是否注册都返回成功", Body: forgotPasswordRequest{}, Data: verificationSent{}},
+		{Method: http.MethodPost, Path: "/user/password/reset", Tag: "user", Summary: "重置密码", Description: "验证码只能使用一次，错误 5 次后作废；新密码不符合规则时验证码不会失效；成功后所有设备都需要重新登录并解除登录锁定", Body: resetPasswordRequest{}, Data: messageData{}},
+		{Method: http.MethodPost, Path: "/user/unlock", Tag: "user", Summary: "解锁账号", Description: "使用账号锁定通知邮件中的令牌解除登录锁定，令牌只能使用一次；重置密码同样会解除锁定", Body: unlockAccountRequest{}, Data: messageData{}},
+		{Method: http.MethodPost, Path: "/user/email/verify/send", Tag: "user", Summary: "发送邮箱验证码", Description: "向当前用户的邮箱发送验证码，用于验证修改后的邮箱或第三方登录带来的邮箱；每分钟最多发送一次", Auth: true, Data: verificationSent{}},
+		{Method: http.MethodPost, Path: "/user/email/verify", Tag: "user", Summary: "验证邮箱", Auth: true, Body: verifyUserEmailRequest{}, Data: emailVerified{}},
+		{Method: http.MethodGet, Path: "/user/mfa", Tag: "user", Summary: "两步验证状态", Auth: true, Data: mfaStatus{}},
+		{Method: http.MethodPost, Path: "/user/mfa/totp/setup", Tag: "user", Summary: "绑定验证器 App", Description: "返回 TOTP 密钥、otpauth 链接和 PNG 二维码（data URI），10 分钟内调用 /user/mfa/totp/enable 确认", Auth: true, Data: service.TOTPSetup{}},
+		{Method: http.MethodPost, Path: "/user/mfa/totp/enable", Tag: "user", Summary: "启用两步验证", Description: "返回 10 个一次性恢复码（只返回这一次）；启用后其他设备需要重新登录", Auth: true, Body: mfaCodeRequest{}, Data: recoveryCodesData{}},
+		{Method: http.MethodPost, Path: "/user/mfa/disable", Tag: "user", Summary: "关闭两步验证", Description: "需要密码和验证码（或恢复码）；验证码 15 分钟内错误 5 次后暂时不能再试（mfa.too_many_attempts）；被要求启用两步验证的管理员不能关闭", Auth: true, Body: disableMFARequest{}, Data: messageData{}},
+		{Method: http.MethodPost, Path: "/user/mfa/recovery-codes", Tag: "user", Summary: "重新生成恢复码", Description: "旧的恢复码全部作废；验证码错误次数与登录第二步、关闭两步验证共同计算", Auth: true, Body: mfaCodeRequest{}, Data: recoveryCodesData{}},
+		{Method: http.MethodGet, Path: "/user/sessions", Tag: "user", Summary: "已登录的设备", Description: "按最近活跃时间倒序，current 标记发起本次请求的设备", Auth: true, Data: []sessionView{}},
+		{Method: http.MethodDelete, Path: "/user/sessions", Tag: "user", Summary: "退出所有设备", Auth: true,
+			Query: []openapi.Parameter{{Name: "keep_current", In: "query", Description: "为 true 时保留当前设备", Schema: &openapi.Schema{Type: "boolean"}}},
+			Data:  sessionsRevoked{}},
+		{Method: http.MethodDelete, Path: "/user/sessions/:id", Tag: "user", Summary: "退出指定设备", Auth: true},
+		{Method: http.MethodGet, Path: "/user/permissions", Tag: "user", Summary: "当前用户的角色和权限", Description: "只能操作自己资源的权限带 :own 后缀，例如 content.update:own", Auth: true, Data: myPermissions{}},
+		{Method: http.MethodGet, Path: "/user/roles", Tag: "user", Summary: "全部角色及其权限", Description: "需要 user.role 权限", Auth: true, Data: []roleView{}},
+		{Method: http.MethodGet, Path: "/user/locked", Tag: "user", Summary: "登录锁定中的用户", Description: "需要 user.ban 权限；按解锁时间升序", Auth: true, Data: []Model.User{}},
+		{Method: http.MethodGet, Path: "/user/list", Tag: "user", Summary: "用户列表", Description: "需要 user.list 权限", Auth: true, Query: pageQuery, Data: userPage{}},
+		{Method: http.MethodGet, Path: "/user/:id", Tag: "user", Summary: "获取用户信息", Auth: true, Data: Model.User{}},
+		{Method: http.MethodPut, Path: "/user/:id", Tag: "user", Summary: "更新用户资料", Description: "修改他人的资料需要 user.update 权限", Auth: true, Body: updateUserRequest{}, Data: Model.User{}},
+		{Method: http.MethodDelete, Path: "/user/:id", Tag: "user", Summary: "删除用户", Description: "删除他人需要 user.delete 权限；同时注销该用户的全部登录", Auth: true},
+		{Method: http.MethodPut, Path: "/user/:id/ban", Tag: "user", Summary: "封禁用户", Description: "需要 user.ban 权限；注销该用户的全部登录，之后无法再登录，请求体可省略", Auth: true, Body: banUserRequest{}, Data: userBanned{}},
+		{Method: http.MethodDelete, Path: "/user/:id/ban", Tag: "user", Summary: "解除封禁", Description: "需要 user.ban 权限", Auth: true, Data: userBanned{}},
+		{Method: http.MethodDelete, Path: "/user/:id/lock", Tag: "user", Summary: "解除登录锁定", Description: "需要 user.ban 权限；同时清除该用户名的登录失败次数", Auth: true, Data: userBanned{}},
+		{Method: http.MethodPut, Path: "/user/:id/role", Tag: "user", Summary: "修改用户角色", Description: "需要 user.role 权限；不能修改自己的角色", Auth: true, Body: updateRoleRequest{}, Data: Model.User{}},
+
+		// 内容
+		{Method: http.MethodGet, Path: "/content", Tag: "content", Summary: "内容列表", Query: pageQuery, Data: contentPage{}},
+		{Method: http.MethodGet, Path: "/content/:id", Tag: "content", Summary: "获取内容详情", Data: Model.Content{}},
+		{Method: http.MethodPost, Path: "/content", Tag: "content", Summary: "创建内容", Description: "需要 content.create 权限", Auth: true, Body: Model.Content{}, Data: Model.Content{}},
+		{Method: http.MethodPut, Path: "/content/:id", Tag: "content", Summary: "更新内容", Description: "作者本人，或拥有修改所有内容的 content.update 权限", Auth: true, Body: updateContentRequest{}, Data: Model.Content{}},
+		{Method: http.MethodDelete, Path: "/content/:id", Tag: "content", Summary: "删除内容", Description: "作者本人，或拥有删除所有内容的 content.delete 权限", Auth: true},
+		{Method: http.MethodPost, Path: "/content/:id/tags", Tag: "content", Summary: "为内容添加标签", Description: "与更新内容的权限相同", Auth: true, Body: contentTagsRequest{}, Data: messageData{}},
+		{Method: http.MethodDelete, Path: "/content/:id/tags/:tagId", Tag: "content", Summary: "移除内容标签", Description: "与更新内容的权限相同", Auth: true, Data: messageData{}},
+
+		// 评论
+		{Method: http.MethodPost, Path: "/comment", Tag: "comment", Summary: "发表评论", Description: "需要 comment.create 权限；评论他人的文章时会邮件通知文章作者", Auth: true, Body: Model.Comment{}, Data: Model.Comment{}},
+		{Method: http.MethodGet, Path: "/comment/content/:contentId", Tag: "comment", Summary: "获取文章评论", Auth: true, Data: []Model.Comment{}},
+		{Method: http.MethodPut, Path: "/comment/:id", Tag: "comment", Summary: "更新评论", Description: "评论作者本人，或拥有修改所有评论的 comment.update 权限", Auth: true, Body: updateCommentRequest{}, Data: Model.Comment{}},
+		{Method: http.MethodDelete, Path: "/comment/:id", Tag: "comment", Summary: "删除评论", Description: "评论作者本人，或拥有删除所有评论的 comment.delete 权限", Auth: true},
+
+		// 标签
+		{Method: http.MethodGet, Path: "/tag", Tag: "tag", Summary: "标签列表（含文章数）", Data: []tagWithCount{}},
+		{Method: http.MethodGet, Path: "/tag/:id", Tag: "tag", Summary: "获取标签", Data: Model.Tag{}},
+		{Method: http.MethodPost, Path: "/tag", Tag: "tag", Summary: "创建标签", Description: "需要 tag.create 权限", Auth: true, Body: Model.Tag{}, Data: Model.Tag{}},
+		{Method: http.MethodPut, Path: "/tag/:id", Tag: "tag", Summary: "更新标签", Description: "需要 tag.update 权限", Auth: true, Body: Model.Tag{}, Data: Model.Tag{}},
+		{Method: http.MethodDelete, Path: "/tag/:id", Tag: "tag", Summary: "删除标签", Description: "需要 tag.delete 权限", Auth: true},
+
+		// 邮件
+		{Method: http.MethodPost, Path: "/email/verify", Tag: "email", Summary: "发送验证码", Description: "验证码通过邮件发送，5 分钟内有效；每分钟最多发送一次，重新发送后之前的验证码失效，过于频繁时返回 email.too_frequent 及需要等待的秒数（wait）", Body: sendVerificationRequest{}, Data: verificationSent{}},
+		{Method: http.MethodPost, Path: "/email/verify/check", Tag: "email", Summary: "校验验证码", Description: "只做预校验，不会使验证码失效；错误次数计入上限", Body: checkVerificationRequest{}, Data: messageData{}},
+
+		// 商品
+		{Method: http.MethodGet, Path: "/goods/items", Tag: "goods", Summary: "查询商品", Query: pageQuery, Data: Model.ItemQueryRequest{}},
+
+		// OAuth
+		{Method: http.MethodGet, Path: "/oauth/platforms", Tag: "oauth", Summary: "已启用的第三方平台", Data: platformList{}},
+		{Method: http.MethodGet, Path: "/oauth/login/:platform", Tag: "oauth", Summary: "发起第三方登录", Description: "返回第三方授权地址，前端跳转到 auth_url", Data: authURLData{}},
+		{Method: http.MethodGet, Path: "/oauth/callback/:platform", Tag: "oauth", Summary: "第三方登录回调",
+			Description: "未登录时返回用户和 token；绑定流程中返回绑定结果",
+			Query: []openapi.Parameter{
+				{Name: "code", In: "query", Description: "授权码", Schema: &openapi.Schema{Type: "string"}},
+				{Name: "state", In: "query", Required: true, Description: "防 CSRF 状态值", Schema: &openapi.Schema{Type: "string"}},
+				{Name: "error", In: "query", Description: "第三方返回的错误", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Data: loginResponse{}},
+		{Method: http.MethodGet, Path: "/oauth/bind/:platform", Tag: "oauth", Summary: "绑定第三方账号", Auth: true, Description: "返回第三方授权地址，前端跳转到 auth_url", Data: authURLData{}},
+		{Method: http.MethodDelete, Path: "/oauth/unbind/:platform", Tag: "oauth", Summary: "解绑第三方账号", Auth: true, Data: messageData{}},
+		{Method: http.MethodGet, Path: "/oauth/accounts", Tag: "oauth", Summary: "已绑定的第三方账号", Auth: true, Data: accountList{}},
+		{Method: http.MethodPost, Path: "/oauth/admin/init-github", Tag: "oauth", Summary: "初始化 GitHub 平台配置", Description: "需要 oauth.manage 权限", Auth: true, Body: initPlatformRequest{}, Data: platformInitData{}},
+	}
+}
+
+// openAPIGenerator 文档生成器
+func openAPIGenerator() *openapi.Generator {
+	return &openapi.Generator{
+		Info: openapi.Info{
+			Title: "Blog API",
+			Description: "业务接口位于 /api/v1；根路径下的同名接口为旧版，已弃用。\n\n" +
+				"除特别说明外，所有接口都返回统一响应结构 {code, status, message, data, errors}：code 为唯一的字符串错误码（成功时为 ok），参数校验失败时 errors 给出字段级错误。\n\n" +
+				"业务接口受限流保护：响应带 RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset 头，超出限制时返回 429（request.rate_limited）和 Retry-After。\n\n" +
+				"message 的语言按 ?lang= 参数、用户的 language 偏好、Accept-Language 依次协商，支持 " + strings.Join(i18n.Supported(), ", ") + "。\n\n" +
+				errorCatalogTable(),
+			Version: version.Version,
+		},
+		Tags: []openapi.Tag{
+			{Name: "user", Description: "用户与认证"},
+			{Name: "content", Description: "文章"},
+			{Name: "comment", Description: "评论"},
+			{Name: "tag", Description: "标签"},
+			{Name: "file", Description: "文件上传与访问"},
+			{Name: "email", Description: "邮件验证码"},
+			{Name: "oauth", Description: "第三方登录"},
+			{Name: "goods", Description: "商品"},
+			{Name: "ops", Description: "运维"},
+		},
+		Envelope: constants.BaseResponse{},
+		Routes:   apiDocs(),
+		Resolve:  resolveDocPath,
+	}
+}
+
+// resolveDocPath 将注册的路径映射到文档表：旧版根路径接口映射到 v1 的路径并标记弃用，
+// /api/<version> 下的接口去掉前缀
+func resolveDocPath(method, path string) (string, bool) {
+	if legacyRoutes[method+" "+path] {
+		return legacyToVersioned(path), true
+	}
+	for _, v := range apiVersions {
+		prefix := versionPrefix(v.Name)
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return strings.TrimPrefix(path, prefix), !v.DeprecatedAt.IsZero()
+		}
+	}
+	return path, false
+}
+
+// errorCatalogTable 以 Markdown 表格列出错误目录
+func errorCatalogTable() string {
+	codes := constants.Catalog()
+	sort.Slice(codes, func(i, j int) bool { return codes[i].GetKey() < codes[j].GetKey() })
+
+	var b strings.Builder
+	b.WriteString("| code | HTTP | 说明 |\n| --- | --- | --- |\n")
+	for _, e := range codes {
+		fmt.Fprintf(&b, "| `%s` | %d | %s |\n", e.GetKey(), e.GetCode(), e.GetMessage())
+	}
+	return b.String()
+}
+
+// registerOpenAPI 注册 /openapi.json 和 /docs，文档在首次请求时根据已注册的路由生成
+func registerOpenAPI(r *gin.Engine) {
+	r.GET("/openapi.json", openapi.SpecHandler(func() *openapi.Document {
+		return openAPIGenerator().Build(r.Routes())
+	}))
+	r.GET("/docs", openapi.UIHandler("Blog API", "/openapi.json"))
+}
@@ -1,7 +1,13 @@
 package controller
 
 import (
+	"blog/config"
+	"blog/i18n"
+	"blog/logger"
+	"blog/metrics"
+	"blog/rbac"
 	"blog/security"
+	"blog/service"
 	"blog/utils"
 
 	"github.com/gin-gonic/gin"
@@ -10,119 +16,32 @@ import (
 // 认证
 
 func InitRoutes(r *gin.Engine) {
-	fileGroup := r.Group("/file")
-	{
-		// 公开访问
-		fileGroup.GET("/listimg", ListImages)           // 获取所有图片
-		fileGroup.GET("/content/:id", GetContentImages) // 根据文章ID获取图片
-
-		// 需要认证的上传接口
-		authFile := fileGroup.Group("")
-		authFile.Use(utils.JWTAuthMiddleware())
-		{
-			authFile.POST("/uploadimg", uploadimg) // 上传图片
-			authFile.POST("/uploadfile", UploadFile)
-		}
-	}
+	oauthService = service.NewOAuthService()
 
-	// 静态文件服务，用于直接访问上传的图片
-	r.Static("/img", GetImageStoragePath())
+	// 健康检查（供负载均衡使用）
+	r.GET("/healthz", Healthz)
+	r.GET("/readyz", Readyz)
 
-	// 用户相关路由
-	userGroup := r.Group("/user")
-	{
-		userGroup.POST("/register", UserRegister)
-		userGroup.POST("/login", UserLogin)
-
-		// 需要认证的路由
-		auth := userGroup.Group("")
-		auth.Use(utils.JWTAuthMiddleware())
-		{
-			auth.POST("/logout", UserLogout)
-			auth.PUT("/password", ChangePassword)
-			auth.GET("/list", ListUsers) // 获取用户列表
-			auth.GET("/:id", GetUserInfo)
-			auth.PUT("/:id", UpdateUserProfile)
-			auth.DELETE("/:id", DeleteUser)
-		}
+	// Prometheus 指标
+	if config.Cfg.Metrics.Enabled {
+		r.GET(config.Cfg.Metrics.Path, metrics.Handler())
 	}
 
-	// 内容相关路由（GET 为公开，其他需要认证）
-	contentGroup := r.Group("/content")
-	{
-		// 公开读接口
-		contentGroup.GET("", ListContents)
-		contentGroup.GET("/:id", GetContent)
-
-		// 需要认证的写接口
-		authContent := contentGroup.Group("/content_auth")
-		authContent.Use(utils.JWTAuthMiddleware())
-		{
-			authContent.POST("", CreateContent)
-			authContent.PUT("/:id", UpdateContent)
-			authContent.DELETE("/:id", DeleteContent)
-			authContent.POST("/:id/tags", AddContentTags)
-			authContent.DELETE("/:id/tags/:tagId", RemoveContentTag)
-		}
-	}
+	// 诊断信息（仅管理员）
+	r.GET("/debug/info", utils.JWTAuthMiddleware(), rbac.RequirePermission(rbac.SystemDebug), DebugInfo)
 
-	// 评论相关路由（全部需要认证）
-	commentGroup := r.Group("/comment")
-	commentGroup.Use(utils.JWTAuthMiddleware())
-	{
-		commentGroup.POST("", CreateComment)
-		commentGroup.GET("/content/:contentId", ListContentComments)
-		commentGroup.PUT("/:id", UpdateComment)
-		commentGroup.DELETE("/:id", DeleteComment)
-	}
+	// API 文档：/openapi.json 和 Swagger UI
+	registerOpenAPI(r)
 
-	// 标签相关路由（GET 为公开，其他需要认证）
-	tagGroup := r.Group("/tag")
-	{
-		// 公开读接口
-		tagGroup.GET("", ListTags)
-		tagGroup.GET("/:id", GetTag)
-
-		// 需要认证的写接口
-		authTag := tagGroup.Group("")
-		authTag.Use(utils.JWTAuthMiddleware())
-		{
-			authTag.POST("", CreateTag)
-			authTag.PUT("/:id", UpdateTag)
-			authTag.DELETE("/:id", DeleteTag)
-		}
-	}
+	// 静态文件服务，用于直接访问上传的图片
+	r.Static("/img", GetImageStoragePath())
 
-	// 邮件相关路由（全部需要认证）
-	emailGroup := r.Group("/email")
-	{
-		emailGroup.POST("/verify", SendVerificationEmail)
-		emailGroup.POST("/verify/check", CheckVerificationCode)
-	}
-	goodsGroup := r.Group("/goods")
-	{
-		goodsGroup.GET("/items", search_goods)
-	}
+	// 业务接口：/api/v1、/api/v2 ...
+	mountAPIVersions(r)
 
-	// OAuth第三方认证相关路由
-	oauthGroup := r.Group("/oauth")
-	{
-		// 公开接口：获取平台列表、发起登录、处理回调
-		oauthGroup.GET("/platforms", GetOAuthPlatforms)
-		oauthGroup.GET("/login/:platform", OAuthLogin)
-		oauthGroup.GET("/callback/:platform", OAuthCallback)
-
-		// 需要认证的接口：绑定/解绑/查看绑定列表
-		authOAuth := oauthGroup.Group("")
-		authOAuth.Use(utils.JWTAuthMiddleware())
-		{
-			authOAuth.GET("/bind/:platform", OAuthBind)
-			authOAuth.DELETE("/unbind/:platform", OAuthUnbind)
-			authOAuth.GET("/accounts", GetUserOAuthAccounts)
-
-			// 管理员接口：初始化平台配置
-			authOAuth.POST("/admin/init-github", InitGitHubPlatform)
-		}
+	// 旧版根路径接口（已弃用）
+	if config.Cfg.API.LegacyRoutes {
+		registerLegacyRoutes(r)
 	}
 }
 
@@ -130,24 +49,36 @@ func SetupMiddlewares(r *gin.Engine) {
 	// CORS中间件 - 使用安全的跨域配置
 	r.Use(security.SetupCORSMiddleware())
 
-	// 日志中间件
-	r.Use(gin.Logger())
+	// 指标中间件
+	if config.Cfg.Metrics.Enabled {
+		r.Use(metrics.GinMiddleware())
+	}
+
+	// 请求ID + 结构化访问日志
+	r.Use(logger.RequestIDMiddleware())
+	r.Use(logger.AccessLogMiddleware())
+
+	// 语言协商（?lang= / 用户偏好 / Accept-Language）
+	r.Use(i18n.Middleware())
 
 	// 恢复中间件
-	r.Use(gin.Recovery())
+	r.Use(logger.RecoveryMiddleware())
 
-	// 可以添加更多中间件
-	// r.Use(authMiddleware()) // 认证中间件
-	// r.Use(rateLimitMiddleware()) // 限流中间件
+	// 限流中间件挂在各 API 版本的路由组上，见 mountAPIVersions
 }
 
 func InitializeServer() *gin.Engine {
 	// 设置Gin模式
-	gin.SetMode(gin.ReleaseMode) // 生产环境使用
+	gin.SetMode(config.Cfg.Server.Mode) // 由配置 server.mode 决定
 
 	// 创建Gin引擎
 	r := gin.New()
 
+	// 只信任配置中的反向代理转发的客户端 IP
+	if err := r.SetTrustedProxies(config.Cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("server.trusted_proxies 配置无效", "error", err)
+	}
+
 	// 设置中间件
 	SetupMiddlewares(r)
 
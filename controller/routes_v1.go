@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"blog/rbac"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	registerAPIVersion(APIVersion{Name: "v1", Routes: registerV1Routes})
+}
+
+// registerV1Routes /api/v1 的全部路由
+func registerV1Routes(api *gin.RouterGroup) {
+	fileGroup := api.Group("/file")
+	{
+		// 公开访问
+		fileGroup.GET("/listimg", ListImages)           // 获取所有图片
+		fileGroup.GET("/content/:id", GetContentImages) // 根据文章ID获取图片
+
+		// 需要认证的上传接口
+		authFile := fileGroup.Group("")
+		authFile.Use(utils.JWTAuthMiddleware())
+		{
+			authFile.POST("/uploadimg", rbac.RequirePermission(rbac.FileUpload), uploadimg) // 上传图片
+			authFile.POST("/uploadfile", rbac.RequirePermission(rbac.FileUpload), UploadFile)
+		}
+	}
+
+	// 用户相关路由
+	userGroup := api.Group("/user")
+	{
+		userGroup.POST("/register", UserRegister)
+		userGroup.POST("/login", UserLogin)
+		userGroup.POST("/login/mfa", LoginMFA) // 登录第二步：两步验证
+		userGroup.POST("/token/refresh", RefreshToken)
+		userGroup.POST("/password/forgot", ForgotPassword)
+		userGroup.POST("/password/reset", ResetPassword)
+		userGroup.POST("/unlock", UnlockAccount) // 使用锁定通知邮件中的令牌解锁
+
+		// 需要认证的路由
+		auth := userGroup.Group("")
+		auth.Use(utils.JWTAuthMiddleware())
+		{
+			auth.POST("/logout", UserLogout)
+			auth.PUT("/password", ChangePassword)
+			auth.GET("/sessions", ListSessions)                                        // 已登录的设备
+			auth.DELETE("/sessions", RevokeAllSessions)                                // 退出所有设备
+			auth.DELETE("/sessions/:id", RevokeSession)                                // 退出指定设备
+			auth.POST("/email/verify/send", SendUserVerificationEmail)                 // 向自己的邮箱发送验证码
+			auth.POST("/email/verify", VerifyUserEmail)                                // 验证自己的邮箱
+			auth.GET("/mfa", GetMFAStatus)                                             // 两步验证状态
+			auth.POST("/mfa/totp/setup", SetupTOTP)                                    // 开始绑定验证器 App
+			auth.POST("/mfa/totp/enable", EnableTOTP)                                  // 提交验证码完成绑定
+			auth.POST("/mfa/disable", DisableMFA)                                      // 关闭两步验证
+			auth.POST("/mfa/recovery-codes", RegenerateRecoveryCodes)                  // 重新生成恢复码
+			auth.GET("/permissions", GetMyPermissions)                                 // 当前用户的角色和权限
+			auth.GET("/list", rbac.RequirePermission(rbac.UserList), ListUsers)        // 获取用户列表
+			auth.GET("/roles", rbac.RequirePermission(rbac.UserRole), ListRoles)       // 全部角色及其权限
+			auth.GET("/locked", rbac.RequirePermission(rbac.UserBan), ListLockedUsers) // 登录锁定中的用户
+			auth.GET("/:id", GetUserInfo)
+			auth.PUT("/:id", UpdateUserProfile)
+			auth.DELETE("/:id", DeleteUser)
+			auth.PUT("/:id/ban", rbac.RequirePermission(rbac.UserBan), BanUser)          // 封禁
+			auth.DELETE("/:id/ban", rbac.RequirePermission(rbac.UserBan), UnbanUser)     // 解除封禁
+			auth.DELETE("/:id/lock", rbac.RequirePermission(rbac.UserBan), UnlockUser)   // 解除登录锁定
+			auth.PUT("/:id/role", rbac.RequirePermission(rbac.UserRole), UpdateUserRole) // 修改角色
+		}
+	}
+
+	// 内容相关路由（GET 为公开，其他需要认证）
+	contentGroup := api.Group("/content")
+	{
+		// 公开读接口
+		contentGroup.GET("", ListContents)
+		contentGroup.GET("/:id", GetContent)
+
+		// 需要认证的写接口
+		authContent := contentGroup.Group("")
+		authContent.Use(utils.JWTAuthMiddleware())
+		{
+			authContent.POST("", rbac.RequirePermission(rbac.ContentCreate), CreateContent)
+			authContent.PUT("/:id", UpdateContent)
+			authContent.DELETE("/:id", DeleteContent)
+			authContent.POST("/:id/tags", AddContentTags)
+			authContent.DELETE("/:id/tags/:tagId", RemoveContentTag)
+		}
+	}
+
+	// 评论相关路由（全部需要认证）
+	commentGroup := api.Group("/comment")
+	commentGroup.Use(utils.JWTAuthMiddleware())
+	{
+		commentGroup.POST("", rbac.RequirePermission(rbac.CommentCreate), CreateComment)
+		commentGroup.GET("/content/:contentId", ListContentComments)
+		commentGroup.PUT("/:id", UpdateComment)
+		commentGroup.DELETE("/:id", DeleteComment)
+	}
+
+	// 标签相关路由（GET 为公开，其他需要认证）
+	tagGroup := api.Group("/tag")
+	{
+		// 公开读接口
+		tagGroup.GET("", ListTags)
+		tagGroup.GET("/:id", GetTag)
+
+		// 需要认证的写接口
+		authTag := tagGroup.Group("")
+		authTag.Use(utils.JWTAuthMiddleware())
+		{
+			authTag.POST("", rbac.RequirePermission(rbac.TagCreate), CreateTag)
+			authTag.PUT("/:id", rbac.RequirePermission(rbac.TagUpdate), UpdateTag)
+			authTag.DELETE("/:id", rbac.RequirePermission(rbac.TagDelete), DeleteTag)
+		}
+	}
+
+	// 邮件相关路由
+	emailGroup := api.Group("/email")
+	{
+		emailGroup.POST("/verify", SendVerificationEmail)
+		emailGroup.POST("/verify/check", CheckVerificationCode)
+	}
+	goodsGroup := api.Group("/goods")
+	{
+		goodsGroup.GET("/items", search_goods)
+	}
+
+	// OAuth第三方认证相关路由
+	oauthGroup := api.Group("/oauth")
+	{
+		// 公开接口：获取平台列表、发起登录、处理回调
+		oauthGroup.GET("/platforms", GetOAuthPlatforms)
+		oauthGroup.GET("/login/:platform", OAuthLogin)
+		oauthGroup.GET("/callback/:platform", OAuthCallback)
+
+		// 需要认证的接口：绑定/解绑/查看绑定列表
+		authOAuth := oauthGroup.Group("")
+		authOAuth.Use(utils.JWTAuthMiddleware())
+		{
+			authOAuth.GET("/bind/:platform", OAuthBind)
+			authOAuth.DELETE("/unbind/:platform", OAuthUnbind)
+			authOAuth.GET("/accounts", GetUserOAuthAccounts)
+
+			// 管理员接口：初始化平台配置
+			authOAuth.POST("/admin/init-github", rbac.RequirePermission(rbac.OAuthManage), InitGitHubPlatform)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"blog/constants"
+	"blog/logger"
+	"blog/service"
+	"blog/session"
+	"blog/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionClient 从请求中提取会话的客户端信息
+func sessionClient(c *gin.Context, device string) session.Client {
+	return session.Client{
+		Device:    device,
+		IP:        service.Utils.GetClientIP(c),
+		UserAgent: service.Utils.GetUserAgent(c),
+	}
+}
+
+// currentSessionID 当前请求所属的会话ID（由 JWT 中间件设置）
+func currentSessionID(c *gin.Context) string {
+	return c.GetString("session_id")
+}
+
+// currentClaims 当前请求的 access token 载荷（由 JWT 中间件设置）
+func currentClaims(c *gin.Context) *utils.Claims {
+	if v, ok := c.Get("claims"); ok {
+		if claims, ok := v.(*utils.Claims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// revokeUserSessions 注销用户的全部登录（改密码、删除、封禁后调用）
+// 数据已经修改成功，注销失败时只记录日志：被封禁和已删除的用户仍会被 JWT 中间件拦截
+func revokeUserSessions(c *gin.Context, userID uint) {
+	if _, err := session.RevokeAll(userID, ""); err != nil {
+		logger.FromGin(c).Error("注销用户会话失败", "target_user_id", userID, "error", err)
+	}
+}
+
+// sessionView 会话列表中的一项
+type sessionView struct {
+	session.Session
+	Current bool `json:"current"` // 是否为发起本次请求的会话
+}
+
+// ListSessions 当前用户已登录的设备
+func ListSessions(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return
+	}
+
+	sessions, err := session.List(userID)
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+
+	current := currentSessionID(c)
+	list := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, sessionView{Session: s, Current: s.ID == current})
+	}
+	constants.SendResponse(c, constants.Success, list)
+}
+
+// RevokeSession 注销指定设备上的登录
+func RevokeSession(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return
+	}
+
+	if err := session.Revoke(userID, c.Param("id")); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			constants.SendResponse(c, constants.SessionNotFound, nil)
+			return
+		}
+		sendSystemError(c, err)
+		return
+	}
+	constants.SendResponse(c, constants.Success, nil)
+}
+
+// revokeSessionsQuery 注销全部会话的查询参数
+type revokeSessionsQuery struct {
+	KeepCurrent bool `form:"keep_current"` // 为 true 时保留当前设备
+}
+
+// RevokeAllSessions 退出所有设备
+func RevokeAllSessions(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return
+	}
+
+	var query revokeSessionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	var except string
+	if query.KeepCurrent {
+		except = currentSessionID(c)
+	}
+	revoked, err := session.RevokeAll(userID, except)
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	constants.SendResponse(c, constants.Success, gin.H{
+		"revoked": revoked,
+		"message": localize(c, "message.sessions_revoked", revoked),
+	})
+}
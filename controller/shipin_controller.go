@@ -18,7 +18,7 @@ func search_goods(c *gin.Context) {
 	query := database.DB.Offset(offset).Limit(pageSize)
 
 	if err := query.Find(&goods).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
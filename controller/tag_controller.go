@@ -4,20 +4,25 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateTag 创建标签
 func CreateTag(c *gin.Context) {
 	var tag Model.Tag
 	if err := c.ShouldBindJSON(&tag); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
-	if err := database.DB.Create(&tag).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+	if err := database.DB.Create(&tag).Error; errors.Is(err, gorm.ErrDuplicatedKey) {
+		constants.SendResponse(c, constants.TagExists, nil)
+		return
+	} else if err != nil {
+		sendSystemError(c, err)
 		return
 	}
 
@@ -28,7 +33,7 @@ func GetTag(c *gin.Context) {
 	id := c.Param("id")
 	var tag Model.Tag
 	if err := database.DB.First(&tag, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "标签不存在"})
+		constants.SendResponse(c, constants.TagNotFound, nil)
 		return
 	}
 	constants.SendResponse(c, constants.Success, tag)
@@ -49,7 +54,7 @@ func ListTags(c *gin.Context) {
 		Joins("LEFT JOIN content_tags ON content_tags.tag_id = tags.tag_id").
 		Group("tags.tag_id").
 		Scan(&tags).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -62,17 +67,20 @@ func UpdateTag(c *gin.Context) {
 	var tag Model.Tag
 
 	if err := database.DB.First(&tag, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{"error": "标签不存在"})
+		constants.SendResponse(c, constants.TagNotFound, nil)
 		return
 	}
 
 	if err := c.ShouldBindJSON(&tag); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{"error": err.Error()})
+		constants.SendValidationError(c, err)
 		return
 	}
 
-	if err := database.DB.Save(&tag).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+	if err := database.DB.Save(&tag).Error; errors.Is(err, gorm.ErrDuplicatedKey) {
+		constants.SendResponse(c, constants.TagExists, nil)
+		return
+	} else if err != nil {
+		sendSystemError(c, err)
 		return
 	}
 
@@ -84,7 +92,7 @@ func DeleteTag(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := database.DB.Delete(&Model.Tag{}, id).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": err.Error()})
+		sendSystemError(c, err)
 		return
 	}
 
@@ -4,156 +4,416 @@ import (
 	"blog/Model"
 	"blog/constants"
 	"blog/database"
+	"blog/i18n"
+	"blog/logger"
+	"blog/metrics"
+	"blog/policy"
+	"blog/rbac"
+	"blog/service"
+	"blog/session"
 	"blog/utils"
-	"fmt"
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// 用户注册
+// registerRequest 注册的请求体，code 为通过 /email/verify 发送到该邮箱的验证码
+type registerRequest struct {
+	Username string `json:"username" binding:"required"` // 长度和字符按 username_policy 检查
+	Password string `json:"password" binding:"required"` // 按 password_policy 检查
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Code     string `json:"code" binding:"required"`
+	Language string `json:"language" binding:"omitempty,language"`
+}
+
+// 用户注册：邮箱必须先通过验证码验证
 func UserRegister(c *gin.Context) {
-	var user Model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	// 用户名和密码规则，违反的规则一次全部返回
+	lang := i18n.FromGin(c)
+	errs := policy.FieldErrors(lang, "username", policy.Username(req.Username))
+	errs = append(errs, policy.FieldErrors(lang, "password", policy.Password(req.Password, req.Username))...)
+	if len(errs) > 0 {
+		constants.SendFieldErrors(c, errs)
 		return
 	}
 
-	// 检查用户名是否已存在
-	if err := database.DB.Where("username = ?", user.Username).First(&Model.User{}).Error; err == nil {
-		constants.SendResponse(c, constants.UserConflict, nil)
+	// 检查用户名和邮箱是否已被使用，放在校验验证码之前，避免验证码被白白消耗
+	if err := database.DB.Where("username = ?", req.Username).First(&Model.User{}).Error; err == nil {
+		constants.SendResponse(c, constants.UserExists, nil)
+		return
+	}
+	if emailTaken(req.Email, 0) {
+		constants.SendResponse(c, constants.UserEmailExists, nil)
+		return
+	}
+
+	if sendCodeError(c, service.ConsumeCode(service.PurposeVerify, req.Email, req.Code)) {
 		return
 	}
 
 	// 使用密码服务加密密码
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, nil)
+		constants.SendResponse(c, constants.SystemError, nil)
 		return
 	}
-	user.Password = hashedPassword
-	user.IsAdmin = false
+	now := time.Now()
+	user := Model.User{
+		Username:        req.Username,
+		Password:        hashedPassword,
+		Email:           req.Email,
+		EmailVerifiedAt: &now,
+		Language:        i18n.Normalize(req.Language),
+	}
 
 	// 保存到数据库
 	if err := database.DB.Create(&user).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, nil)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// 并发注册时唯一索引兜底
+			constants.SendResponse(c, constants.UserExists, nil)
+			return
+		}
+		constants.SendResponse(c, constants.SystemError, nil)
 		return
 	}
 
 	user.Password = "" // 清除密码
-	constants.SendResponse(c, constants.UserSuccess, user)
+	constants.SendResponse(c, constants.Success, user)
+}
+
+// emailTaken 邮箱是否已被其他用户使用（不区分大小写），exceptUserID 为 0 时检查所有用户
+func emailTaken(email string, exceptUserID uint) bool {
+	var count int64
+	database.DB.Model(&Model.User{}).
+		Where("LOWER(email) = ? AND user_id <> ?", strings.ToLower(strings.TrimSpace(email)), exceptUserID).
+		Count(&count)
+	return count > 0
 }
 
 // 用户登录
 func UserLogin(c *gin.Context) {
 	var loginReq Model.LoginRequest
 	if err := c.ShouldBindJSON(&loginReq); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+		constants.SendValidationError(c, err)
 		return
 	}
 
-	var user Model.User
-	if err := database.DB.Where("username = ?", loginReq.Username).First(&user).Error; err != nil {
-		constants.SendResponse(c, constants.UserLoginError, nil)
+	// 用户名不存在时 user 为 nil，仍然参与失败计数，响应与密码错误一致
+	var user *Model.User
+	var found Model.User
+	if err := database.DB.Where("username = ?", loginReq.Username).First(&found).Error; err == nil {
+		user = &found
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		sendSystemError(c, err)
 		return
 	}
 
-	// 使用密码服务验证密码
-	if !utils.CheckPassword(loginReq.Password, user.Password) {
-		constants.SendResponse(c, constants.UserLoginError, nil)
+	ip := service.Utils.GetClientIP(c)
+	if wait, err := service.CheckLoginAllowed(loginReq.Username, ip, user); err != nil {
+		sendLoginBlocked(c, wait, err)
 		return
 	}
 
-	// 生成JWT Token（确保 GenerateToken 接收 (int64, string)）
-	token, err := utils.GenerateToken(int64(user.UserID), user.Username)
-	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{"error": "生成token失败"})
+	// 使用密码服务验证密码；用户不存在时同样执行一次 bcrypt，使响应时间与密码错误一致
+	var passwordOK bool
+	if user == nil || user.Password == "" {
+		passwordOK = utils.CheckPasswordDummy(loginReq.Password)
+	} else {
+		passwordOK = utils.CheckPassword(loginReq.Password, user.Password)
+	}
+	if !passwordOK {
+		wait, err := service.RecordLoginFailure(loginReq.Username, ip, user, i18n.FromGin(c))
+		if errors.Is(err, service.ErrAccountLocked) {
+			sendLoginBlocked(c, wait, err)
+			return
+		}
+		if err != nil {
+			logger.FromGin(c).Error("记录登录失败次数失败", "error", err)
+		}
+		metrics.Logins.WithLabelValues("password", "failed").Inc()
+		var data interface{}
+		if wait > 0 {
+			data = gin.H{"wait": waitSeconds(wait)}
+		}
+		constants.SendResponse(c, constants.AuthInvalidCredentials, data)
 		return
 	}
+	if err := service.ResetLoginFailures(user.Username); err != nil {
+		logger.FromGin(c).Warn("清除登录失败次数失败", "error", err)
+	}
 
-	// 存储 token 到 Redis，键名示例：user_token:<userID>
-	tokenKey := fmt.Sprintf("user_token:%d", user.UserID)
-	// 设置过期时间，与 token 的有效期保持一致（这里示例 24 小时）
-	if err := database.SetString(tokenKey, token, 24*time.Hour); err != nil {
-		fmt.Printf("Redis SetString Error: %v\n", err) // 👈 新增：打印具体错误到控制台
-		constants.SendResponse(c, constants.UserRedisError, gin.H{"error": "存储token失败"})
+	if user.IsBanned() {
+		metrics.Logins.WithLabelValues("password", "failed").Inc()
+		constants.SendResponse(c, constants.AuthUserBanned, nil)
 		return
 	}
 
+	completeLogin(c, user, loginReq.Device, "password")
+}
+
+// sendLoginBlocked 登录被限制（失败过多需要等待，或账号被锁定）时的响应，wait 为需要等待的秒数，同时写入 Retry-After
+func sendLoginBlocked(c *gin.Context, wait time.Duration, err error) {
+	var status constants.StatusCode
+	switch {
+	case errors.Is(err, service.ErrAccountLocked):
+		metrics.Logins.WithLabelValues("password", "locked").Inc()
+		status = constants.AuthAccountLocked
+	case errors.Is(err, service.ErrLoginThrottled):
+		metrics.Logins.WithLabelValues("password", "throttled").Inc()
+		status = constants.AuthLoginThrottled
+	default:
+		sendSystemError(c, err)
+		return
+	}
+	seconds := waitSeconds(wait)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	constants.SendResponse(c, status, gin.H{"wait": seconds})
+}
+
+// waitSeconds 需要等待的时间向上取整到秒，至少 1 秒
+func waitSeconds(wait time.Duration) int {
+	return max(int((wait+time.Second-1)/time.Second), 1)
+}
+
+// loginResponse 登录成功的响应数据（密码登录和第三方登录共用）
+type loginResponse struct {
+	User Model.User `json:"user"`
+	service.TokenPair
+	// MFAEnrollmentRequired 管理员被要求启用两步验证但尚未启用，启用前不能执行管理操作
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+}
+
+// mfaChallengeResponse 需要两步验证时的登录响应，客户端凭 mfa_token 和验证码调用 /user/login/mfa
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// completeLogin 身份验证通过后：启用了两步验证的用户返回临时令牌，等待第二步验证；
+// 其他用户直接签发令牌对。method 为登录方式（password / oauth），用于指标
+func completeLogin(c *gin.Context, user *Model.User, device, method string) {
+	if user.MFAEnabled() {
+		token, err := service.CreateMFAChallenge(user.UserID, device)
+		if err != nil {
+			if errors.Is(err, service.ErrMFATooManyAttempts) {
+				metrics.Logins.WithLabelValues(method, "mfa_blocked").Inc()
+			}
+			sendMFAError(c, err)
+			return
+		}
+		metrics.Logins.WithLabelValues(method, "mfa_required").Inc()
+		constants.SendResponse(c, constants.Success, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    token,
+			ExpiresIn:   int(service.MFAChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	// 签发 access token + refresh token
+	tokens, err := service.IssueTokenPair(user.UserID, user.Username, sessionClient(c, device))
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+
+	metrics.Logins.WithLabelValues(method, "success").Inc()
 	user.Password = "" // 清除密码
-	constants.SendResponse(c, constants.UserSuccess, gin.H{
-		"user":  user,
-		"token": token,
+	constants.SendResponse(c, constants.Success, loginResponse{
+		User:                  *user,
+		TokenPair:             *tokens,
+		MFAEnrollmentRequired: service.MFAEnrollmentRequired(user),
 	})
 }
 
-// UserLogout 用户登出
+// refreshTokenRequest 刷新令牌的请求体
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken 使用 refresh token 换发新的令牌对
+func RefreshToken(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	tokens, err := service.RefreshTokenPair(req.RefreshToken, sessionClient(c, ""))
+	switch {
+	case errors.Is(err, service.ErrRefreshTokenInvalid):
+		metrics.TokenRefreshes.WithLabelValues("invalid").Inc()
+		constants.SendResponse(c, constants.AuthRefreshInvalid, nil)
+		return
+	case errors.Is(err, service.ErrRefreshTokenReused):
+		metrics.TokenRefreshes.WithLabelValues("reused").Inc()
+		constants.SendResponse(c, constants.AuthRefreshReused, nil)
+		return
+	case errors.Is(err, service.ErrUserBanned):
+		metrics.TokenRefreshes.WithLabelValues("invalid").Inc()
+		constants.SendResponse(c, constants.AuthUserBanned, nil)
+		return
+	case err != nil:
+		sendSystemError(c, err)
+		return
+	}
+
+	metrics.TokenRefreshes.WithLabelValues("success").Inc()
+	constants.SendResponse(c, constants.Success, tokens)
+}
+
+// UserLogout 用户登出：吊销当前 access token 并注销当前会话（其 refresh token 随之失效）
 func UserLogout(c *gin.Context) {
-	// 如果使用了会话或令牌，在这里清除它们
-	constants.SendResponse(c, constants.UserSuccess, nil)
+	userID, err := getUserID(c)
+	if err != nil {
+		constants.SendResponse(c, constants.Unauthorized, nil)
+		return
+	}
+
+	if claims := currentClaims(c); claims != nil {
+		if err := utils.RevokeToken(claims); err != nil {
+			sendSystemError(c, err)
+			return
+		}
+	}
+	if err := session.Revoke(userID, currentSessionID(c)); err != nil && !errors.Is(err, session.ErrNotFound) {
+		sendSystemError(c, err)
+		return
+	}
+
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message": localize(c, "message.logged_out"),
+	})
+}
+
+// changePasswordRequest 修改密码的请求体
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // 按 password_policy 检查
 }
 
 // ChangePassword 修改密码
 func ChangePassword(c *gin.Context) {
-	type PasswordChange struct {
-		OldPassword string `json:"old_password" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
-	}
-
-	var pwdChange PasswordChange
+	var pwdChange changePasswordRequest
 	if err := c.ShouldBindJSON(&pwdChange); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, gin.H{
-			"error": "无效的请求参数",
-		})
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	// 从认证中间件获取用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{
-			"error": "未登录或会话已过期",
-		})
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 
 	var user Model.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		constants.SendResponse(c, constants.UserNotFound, gin.H{
-			"error": "用户不存在",
-		})
+		constants.SendResponse(c, constants.UserNotFound, nil)
+		return
+	}
+
+	if violations := policy.Password(pwdChange.NewPassword, user.Username); len(violations) > 0 {
+		constants.SendFieldErrors(c, policy.FieldErrors(i18n.FromGin(c), "new_password", violations))
 		return
 	}
 
 	// 验证旧密码
-	if utils.CheckPassword(pwdChange.OldPassword, user.Password) {
-		constants.SendResponse(c, constants.UserUnauthorized, gin.H{
-			"error": "原密码错误",
-		})
+	if !utils.CheckPassword(pwdChange.OldPassword, user.Password) {
+		constants.SendResponse(c, constants.UserPasswordIncorrect, nil)
 		return
 	}
 
 	// 加密新密码
 	hashedNewPassword, err := utils.HashPassword(pwdChange.NewPassword)
 	if err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error": "密码加密失败",
-		})
+		constants.SendResponse(c, constants.SystemError, nil)
 		return
 	}
 
 	// 更新密码
 	user.Password = hashedNewPassword
 	if err := database.DB.Save(&user).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, gin.H{
-			"error": "密码更新失败",
-		})
+		logger.FromGin(c).Error("密码更新失败", "error", err)
+		constants.SendResponse(c, constants.SystemError, nil)
+		return
+	}
+
+	// 密码可能已泄露，注销包括当前设备在内的全部登录
+	if claims := currentClaims(c); claims != nil {
+		_ = utils.RevokeToken(claims)
+	}
+	revokeUserSessions(c, user.UserID)
+
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message": localize(c, "message.password_changed"),
+	})
+}
+
+// forgotPasswordRequest 找回密码的请求体
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword 向注册邮箱发送找回密码验证码
+// 无论邮箱是否注册都返回相同的结果，避免通过该接口探测用户
+func ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	if err := service.RequestPasswordReset(req.Email, i18n.FromGin(c)); err != nil {
+		sendSystemError(c, err)
+		return
+	}
+
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message":   localize(c, "message.password_reset_sent"),
+		"expire_in": int(service.PasswordResetTTL.Seconds()),
+		"resend_in": int(service.CodeResendInterval.Seconds()),
+	})
+}
+
+// resetPasswordRequest 重置密码的请求体
+type resetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Code        string `json:"code" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // 按 password_policy 检查
+}
+
+// ResetPassword 使用找回密码验证码设置新密码，成功后所有设备都需要重新登录
+func ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	err := service.ResetPassword(req.Email, req.Code, req.NewPassword)
+	var policyErr *policy.Error
+	if errors.As(err, &policyErr) {
+		constants.SendFieldErrors(c, policy.FieldErrors(i18n.FromGin(c), "new_password", policyErr.Violations))
+		return
+	}
+	if sendCodeError(c, err) {
 		return
 	}
 
-	constants.SendResponse(c, constants.UserSuccess, gin.H{
-		"message": "密码修改成功",
+	constants.SendResponse(c, constants.Success, gin.H{
+		"message": localize(c, "message.password_reset"),
 	})
 }
 
@@ -162,7 +422,7 @@ func GetUserInfo(c *gin.Context) {
 	id := c.Param("id")
 	userID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+		constants.SendResponse(c, constants.BadRequest, nil)
 		return
 	}
 
@@ -173,33 +433,11 @@ func GetUserInfo(c *gin.Context) {
 	}
 
 	user.Password = "" // 清除密码
-	constants.SendResponse(c, constants.UserSuccess, user)
-}
-
-// checkIsAdmin 检查用户是否为管理员
-func checkIsAdmin(userID uint) bool {
-	var user Model.User
-	if err := database.DB.Select("is_admin").First(&user, userID).Error; err != nil {
-		return false
-	}
-	return user.IsAdmin
+	constants.SendResponse(c, constants.Success, user)
 }
 
-// ListUsers 获取所有用户列表（仅限管理员）
+// ListUsers 获取所有用户列表（需要 user.list 权限）
 func ListUsers(c *gin.Context) {
-	// 获取当前登录用户ID
-	currentUserID, exists := c.Get("user_id")
-	if !exists {
-		constants.SendResponse(c, constants.UserUnauthorized, nil)
-		return
-	}
-
-	// 检查管理员权限
-	if !checkIsAdmin(uint(currentUserID.(int64))) {
-		constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "需要管理员权限"})
-		return
-	}
-
 	var users []Model.User
 	var total int64
 
@@ -218,7 +456,7 @@ func ListUsers(c *gin.Context) {
 	database.DB.Model(&Model.User{}).Count(&total)
 
 	if err := database.DB.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, nil)
+		constants.SendResponse(c, constants.SystemError, nil)
 		return
 	}
 
@@ -227,7 +465,7 @@ func ListUsers(c *gin.Context) {
 		users[i].Password = ""
 	}
 
-	constants.SendResponse(c, constants.UserSuccess, gin.H{
+	constants.SendResponse(c, constants.Success, gin.H{
 		"list":      users,
 		"total":     total,
 		"page":      page,
@@ -235,29 +473,34 @@ func ListUsers(c *gin.Context) {
 	})
 }
 
+// updateUserRequest 更新用户资料的请求体
+type updateUserRequest struct {
+	Email    string  `json:"email" binding:"omitempty,email,max=100"` // 修改邮箱后需要重新验证
+	Avatar   string  `json:"avatar"`
+	Language *string `json:"language" binding:"omitempty,language"` // 传空字符串表示跟随 Accept-Language
+}
+
 // UpdateUserProfile 更新用户资料
 func UpdateUserProfile(c *gin.Context) {
 	id := c.Param("id")
 	targetUserID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+		constants.SendResponse(c, constants.BadRequest, nil)
 		return
 	}
 
 	// 获取当前登录用户ID
 	currentUserIDVal, exists := c.Get("user_id")
 	if !exists {
-		constants.SendResponse(c, constants.UserUnauthorized, nil)
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	currentUserID := uint(currentUserIDVal.(int64))
 
-	// 权限检查：只有管理员或用户自己可以修改
-	if currentUserID != uint(targetUserID) {
-		if !checkIsAdmin(currentUserID) {
-			constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权修改此用户信息"})
-			return
-		}
+	// 权限检查：用户自己，或拥有 user.update 权限
+	if currentUserID != uint(targetUserID) &&
+		!rbac.Authorize(c, rbac.UserUpdate, uint(targetUserID), constants.UserForbidden) {
+		return
 	}
 
 	var user Model.User
@@ -266,43 +509,42 @@ func UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
-	var updateData struct {
-		Email   string `json:"email"`
-		Avatar  string `json:"avatar"`
-		IsAdmin *bool  `json:"is_admin"` // 使用指针以区分是否传递了该字段
-	}
+	var updateData updateUserRequest
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+		constants.SendValidationError(c, err)
 		return
 	}
 
 	updates := make(map[string]interface{})
-	if updateData.Email != "" {
+	if updateData.Email != "" && !strings.EqualFold(updateData.Email, user.Email) {
+		if emailTaken(updateData.Email, user.UserID) {
+			constants.SendResponse(c, constants.UserEmailExists, nil)
+			return
+		}
 		updates["email"] = updateData.Email
+		updates["email_verified_at"] = nil
 	}
 	if updateData.Avatar != "" {
 		updates["avatar"] = updateData.Avatar
 	}
-
-	// 只有管理员可以修改 IsAdmin 状态
-	if updateData.IsAdmin != nil {
-		if checkIsAdmin(currentUserID) {
-			updates["is_admin"] = *updateData.IsAdmin
-		} else {
-			// 如果非管理员尝试修改权限，可以选择忽略或报错，这里选择忽略并记录日志或仅忽略
-		}
+	if updateData.Language != nil {
+		updates["language"] = i18n.Normalize(*updateData.Language)
 	}
 
 	if len(updates) > 0 {
 		if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
-			constants.SendResponse(c, constants.UserSystemError, nil)
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				constants.SendResponse(c, constants.UserEmailExists, nil)
+				return
+			}
+			constants.SendResponse(c, constants.SystemError, nil)
 			return
 		}
 	}
 
 	user.Password = "" // 清除密码
-	constants.SendResponse(c, constants.UserSuccess, user)
+	constants.SendResponse(c, constants.Success, user)
 }
 
 // DeleteUser 删除用户
@@ -310,31 +552,241 @@ func DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	targetUserID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		constants.SendResponse(c, constants.UserBadRequest, nil)
+		constants.SendResponse(c, constants.BadRequest, nil)
 		return
 	}
 
 	// 获取当前登录用户ID
 	currentUserIDVal, exists := c.Get("user_id")
 	if !exists {
-		constants.SendResponse(c, constants.UserUnauthorized, nil)
+		constants.SendResponse(c, constants.Unauthorized, nil)
 		return
 	}
 	currentUserID := uint(currentUserIDVal.(int64))
 
-	// 权限检查：只有管理员可以删除用户（或者用户注销自己，视需求而定）
-	// 这里假设只有管理员可以删除其他用户，用户可以删除自己
-	if currentUserID != uint(targetUserID) {
-		if !checkIsAdmin(currentUserID) {
-			constants.SendResponse(c, constants.UserForbidden, gin.H{"error": "无权删除此用户"})
+	// 权限检查：用户可以删除自己，删除其他用户需要 user.delete 权限
+	if currentUserID != uint(targetUserID) &&
+		!rbac.Authorize(c, rbac.UserDelete, uint(targetUserID), constants.UserForbidden) {
+		return
+	}
+
+	if err := database.DB.Delete(&Model.User{}, targetUserID).Error; err != nil {
+		constants.SendResponse(c, constants.SystemError, nil)
+		return
+	}
+	if err := database.DB.Where("user_id = ?", targetUserID).Delete(&Model.MFARecoveryCode{}).Error; err != nil {
+		logger.FromGin(c).Error("删除用户的恢复码失败", "target_user_id", targetUserID, "error", err)
+	}
+	revokeUserSessions(c, uint(targetUserID))
+
+	constants.SendResponse(c, constants.Success, nil)
+}
+
+// banUserRequest 封禁用户的请求体（可省略）
+type banUserRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// BanUser 封禁用户并注销其全部登录（需要 user.ban 权限）
+func BanUser(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if currentUserID, _ := getUserID(c); currentUserID == user.UserID {
+		constants.SendResponse(c, constants.UserBanSelf, nil)
+		return
+	}
+
+	var req banUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			constants.SendValidationError(c, err)
 			return
 		}
 	}
 
-	if err := database.DB.Delete(&Model.User{}, targetUserID).Error; err != nil {
-		constants.SendResponse(c, constants.UserSystemError, nil)
+	now := time.Now()
+	if err := database.DB.Model(user).Updates(map[string]interface{}{
+		"banned_at":  now,
+		"ban_reason": req.Reason,
+	}).Error; err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	revokeUserSessions(c, user.UserID)
+	logger.FromGin(c).Info("封禁用户", "target_user_id", user.UserID, "reason", req.Reason)
+
+	user.Password = ""
+	constants.SendResponse(c, constants.Success, gin.H{
+		"user":    user,
+		"message": localize(c, "message.user_banned"),
+	})
+}
+
+// UnbanUser 解除封禁（需要 user.ban 权限）
+func UnbanUser(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
 		return
 	}
 
-	constants.SendResponse(c, constants.UserSuccess, nil)
+	if err := database.DB.Model(user).Updates(map[string]interface{}{
+		"banned_at":  nil,
+		"ban_reason": "",
+	}).Error; err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	logger.FromGin(c).Info("解除封禁", "target_user_id", user.UserID)
+
+	user.Password = ""
+	constants.SendResponse(c, constants.Success, gin.H{
+		"user":    user,
+		"message": localize(c, "message.user_unbanned"),
+	})
+}
+
+// ListLockedUsers 因多次登录失败处于锁定中的用户（需要 user.ban 权限）
+func ListLockedUsers(c *gin.Context) {
+	users, err := service.LockedAccounts()
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+	constants.SendResponse(c, constants.Success, users)
+}
+
+// UnlockUser 解除用户的登录锁定（需要 user.ban 权限）
+func UnlockUser(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if err := service.UnlockAccount(user); err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	logger.FromGin(c).Info("解除登录锁定", "target_user_id", user.UserID)
+
+	user.Password = ""
+	constants.SendResponse(c, constants.Success, gin.H{
+		"user":    user,
+		"message": localize(c, "message.account_unlocked"),
+	})
+}
+
+// unlockAccountRequest 通过邮件解锁账号的请求体
+type unlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UnlockAccount 使用锁定通知邮件中的令牌解锁自己的账号
+func UnlockAccount(c *gin.Context) {
+	var req unlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	user, err := service.UnlockAccountWithToken(req.Token)
+	if errors.Is(err, service.ErrUnlockTokenInvalid) {
+		constants.SendResponse(c, constants.AuthUnlockTokenInvalid, nil)
+		return
+	}
+	if err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	logger.FromGin(c).Info("通过邮件解锁账号", "target_user_id", user.UserID)
+
+	constants.SendResponse(c, constants.Success, gin.H{"message": localize(c, "message.account_unlocked")})
+}
+
+// updateRoleRequest 修改用户角色的请求体
+type updateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor author commenter"`
+}
+
+// UpdateUserRole 修改用户的角色（需要 user.role 权限），不能修改自己的角色
+func UpdateUserRole(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+	if currentUserID, _ := getUserID(c); currentUserID == user.UserID {
+		constants.SendResponse(c, constants.UserRoleSelf, nil)
+		return
+	}
+
+	var req updateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		constants.SendValidationError(c, err)
+		return
+	}
+
+	previous := user.Role
+	if err := database.DB.Model(user).Update("role", req.Role).Error; err != nil {
+		sendSystemError(c, err)
+		return
+	}
+	logger.FromGin(c).Info("修改用户角色", "target_user_id", user.UserID, "from", previous, "to", req.Role)
+
+	user.Password = ""
+	constants.SendResponse(c, constants.Success, user)
+}
+
+// roleView 角色及其权限
+type roleView struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"` // 只能操作自己资源的权限带 :own 后缀
+}
+
+// ListRoles 全部角色及其权限（需要 user.role 权限）
+func ListRoles(c *gin.Context) {
+	names := rbac.RoleNames()
+	list := make([]roleView, 0, len(names))
+	for _, name := range names {
+		list = append(list, roleView{Role: name, Permissions: rbac.Permissions(name)})
+	}
+	constants.SendResponse(c, constants.Success, list)
+}
+
+// myPermissions 当前用户的角色和权限
+type myPermissions struct {
+	roleView
+	// MFAEnrollmentRequired 必须先启用两步验证才能使用管理权限，启用前只有 author 的权限
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+}
+
+// GetMyPermissions 当前用户的角色和权限，供前端决定显示哪些操作
+func GetMyPermissions(c *gin.Context) {
+	user := loadCurrentUser(c)
+	if user == nil {
+		return
+	}
+	constants.SendResponse(c, constants.Success, myPermissions{
+		roleView:              roleView{Role: user.Role, Permissions: rbac.Permissions(user.Role)},
+		MFAEnrollmentRequired: service.MFAEnrollmentRequired(user),
+	})
+}
+
+// loadTargetUser 读取路径参数 :id 指定的用户，失败时已写入响应；权限由路由上的 rbac.RequirePermission 检查
+func loadTargetUser(c *gin.Context) (*Model.User, bool) {
+	targetUserID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		constants.SendResponse(c, constants.BadRequest, nil)
+		return nil, false
+	}
+
+	var user Model.User
+	if err := database.DB.First(&user, targetUserID).Error; err != nil {
+		constants.SendResponse(c, constants.UserNotFound, nil)
+		return nil, false
+	}
+	return &user, true
 }
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"blog/config"
+	"blog/logger"
+	"blog/metrics"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var DB *gorm.DB
+
+// InitDB 根据配置的 DSN 选择数据库后端（SQLite / PostgreSQL / MySQL）并建立连接
+// 表结构由 migrations 包负责，连接后需要执行迁移
+func InitDB() {
+	cfg := config.Cfg.Database
+
+	driver, dsn, err := cfg.Driver()
+	if err != nil {
+		logger.Fatal("解析数据库配置失败", "error", err)
+	}
+
+	var dialector gorm.Dialector
+	switch driver {
+	case config.DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case config.DriverMySQL:
+		dialector = mysql.Open(dsn)
+	default:
+		dialector = openSQLite(dsn)
+	}
+
+	var openErr error
+	DB, openErr = gorm.Open(dialector, &gorm.Config{
+		// SQL 日志输出到结构化日志：只记录错误和慢查询
+		Logger: gormlogger.NewSlogLogger(slog.Default(), gormlogger.Config{
+			LogLevel:                  gormlogger.Warn,
+			SlowThreshold:             200 * time.Millisecond,
+			IgnoreRecordNotFoundError: true,
+		}),
+		// 将各数据库的唯一约束等错误统一转换为 gorm.ErrDuplicatedKey 等
+		TranslateError: true,
+	})
+	if openErr != nil {
+		logger.Fatal("连接数据库失败", "driver", driver, "error", openErr, "error_type", fmt.Sprintf("%T", openErr))
+	}
+
+	// 通过 GORM 回调统计查询耗时
+	if config.Cfg.Metrics.Enabled {
+		if err := DB.Use(metrics.GormPlugin{}); err != nil {
+			slog.Warn("注册数据库指标插件失败", "error", err)
+		}
+	}
+
+	// 连接池配置
+	sqlDB, err := DB.DB()
+	if err != nil {
+		logger.Fatal("获取数据库连接池失败", "error", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	slog.Info("成功连接到数据库", "dialect", DB.Dialector.Name())
+}
+
+// CloseDB 关闭数据库连接池
+func CloseDB() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Quote 按当前数据库方言为标识符加引号，用于 order 等保留字列名
+// 例如 SQLite/MySQL 下为 `order`，PostgreSQL 下为 "order"
+func Quote(name string) string {
+	var b strings.Builder
+	DB.Dialector.QuoteTo(&b, name)
+	return b.String()
+}
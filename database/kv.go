@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNil 键或哈希字段不存在（Redis 实现中对应 redis.Nil）
+var ErrNil = errors.New("kv: key不存在")
+
+// TTL 的特殊返回值，与 Redis 保持一致
+const (
+	TTLNoExpire time.Duration = -1 // 键存在但没有过期时间
+	TTLNotExist time.Duration = -2 // 键不存在
+)
+
+// KV 键值存储抽象，Redis 和进程内存两种实现
+type KV interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	Incr(ctx context.Context, key string) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
+	// IncrWithTTL 原子地自增并在键没有过期时间时设置过期时间（通常是第一次自增时），
+	// 用于按时间窗口计数，避免自增后设置过期时间失败导致计数器永不过期
+	IncrWithTTL(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	HSet(ctx context.Context, key, field, value string) error
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) error
+	// Scan 按游标分批遍历匹配 pattern 的键，返回的游标为0表示遍历结束
+	Scan(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error)
+	// GetMany 批量读取，结果中不包含不存在的键（Redis 实现使用 pipeline）
+	GetMany(ctx context.Context, keys []string) (map[string]string, error)
+	// SetMany 批量写入，所有键使用相同的过期时间（Redis 实现使用 pipeline）
+	SetMany(ctx context.Context, values map[string]string, expiration time.Duration) error
+	Ping(ctx context.Context) error
+	Close() error
+	// Driver 实现名称：redis / memory
+	Driver() string
+}
+
+// Store 全局键值存储，由 InitKV 根据配置初始化
+var Store KV
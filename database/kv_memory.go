@@ -0,0 +1,447 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// errWrongType 对字符串键执行哈希操作（或相反）时返回，对应 Redis 的 WRONGTYPE
+var errWrongType = errors.New("kv: 键的类型不匹配")
+
+type memoryEntry struct {
+	value     string
+	hash      map[string]string // 非 nil 表示哈希类型
+	expiresAt time.Time         // 零值表示永不过期
+	seq       uint64            // 写入顺序，作为 Scan 的游标
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryKV 进程内的 KV 实现，适合单实例、无 Redis 的小型部署
+// 数据不持久化，进程重启后登录状态和验证码会失效
+type MemoryKV struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	seq     uint64 // 最近一次分配的写入序号
+	stop    chan struct{}
+	once    sync.Once
+}
+
+var _ KV = (*MemoryKV)(nil)
+
+// NewMemoryKV 创建内存 KV，并启动后台清理过期键的协程
+func NewMemoryKV() *MemoryKV {
+	m := &MemoryKV{
+		entries: make(map[string]*memoryEntry),
+		stop:    make(chan struct{}),
+	}
+	go m.janitor(time.Minute)
+	return m
+}
+
+// janitor 定期清理过期键，避免只写不读的键一直占用内存
+func (m *MemoryKV) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			m.mu.Lock()
+			for k, e := range m.entries {
+				if e.expired(now) {
+					delete(m.entries, k)
+				}
+			}
+			m.mu.Unlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// lookup 取出未过期的键，已过期的顺便删除，调用方需持有锁
+func (m *MemoryKV) lookup(key string) *memoryEntry {
+	e, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		delete(m.entries, key)
+		return nil
+	}
+	return e
+}
+
+// put 写入新的键值并分配写入序号，调用方需持有锁
+func (m *MemoryKV) put(key string, e *memoryEntry) {
+	m.seq++
+	e.seq = m.seq
+	m.entries[key] = e
+}
+
+func (m *MemoryKV) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return "", ErrNil
+	}
+	if e.hash != nil {
+		return "", errWrongType
+	}
+	return e.value, nil
+}
+
+func (m *MemoryKV) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &memoryEntry{value: value}
+	if expiration > 0 {
+		e.expiresAt = time.Now().Add(expiration)
+	}
+	m.put(key, e)
+	return nil
+}
+
+func (m *MemoryKV) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range keys {
+		delete(m.entries, k)
+	}
+	return nil
+}
+
+func (m *MemoryKV) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookup(key) != nil, nil
+}
+
+func (m *MemoryKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return TTLNotExist, nil
+	}
+	if e.expiresAt.IsZero() {
+		return TTLNoExpire, nil
+	}
+	// 与 Redis 一致，精度为秒
+	return time.Until(e.expiresAt).Round(time.Second), nil
+}
+
+func (m *MemoryKV) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return nil
+	}
+	if expiration <= 0 {
+		delete(m.entries, key)
+		return nil
+	}
+	e.expiresAt = time.Now().Add(expiration)
+	return nil
+}
+
+func (m *MemoryKV) Incr(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(key, 1)
+}
+
+func (m *MemoryKV) Decr(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(key, -1)
+}
+
+func (m *MemoryKV) IncrWithTTL(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, err := m.incrByLocked(key, 1)
+	if err != nil {
+		return 0, err
+	}
+	if e := m.entries[key]; e.expiresAt.IsZero() && expiration > 0 {
+		e.expiresAt = time.Now().Add(expiration)
+	}
+	return n, nil
+}
+
+// incrBy 与 Redis INCRBY 一致：键不存在时从0开始，保留原有过期时间
+func (m *MemoryKV) incrBy(key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incrByLocked(key, delta)
+}
+
+// incrByLocked 同 incrBy，调用方需持有锁
+func (m *MemoryKV) incrByLocked(key string, delta int64) (int64, error) {
+	e := m.lookup(key)
+	if e == nil {
+		e = &memoryEntry{value: "0"}
+		m.put(key, e)
+	}
+	if e.hash != nil {
+		return 0, errWrongType
+	}
+
+	n, err := strconv.ParseInt(e.value, 10, 64)
+	if err != nil {
+		return 0, errors.New("kv: 值不是整数")
+	}
+	n += delta
+	e.value = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (m *MemoryKV) HSet(ctx context.Context, key, field, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		e = &memoryEntry{hash: make(map[string]string)}
+		m.put(key, e)
+	}
+	if e.hash == nil {
+		return errWrongType
+	}
+	e.hash[field] = value
+	return nil
+}
+
+func (m *MemoryKV) HGet(ctx context.Context, key, field string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return "", ErrNil
+	}
+	if e.hash == nil {
+		return "", errWrongType
+	}
+	v, ok := e.hash[field]
+	if !ok {
+		return "", ErrNil
+	}
+	return v, nil
+}
+
+func (m *MemoryKV) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]string)
+	e := m.lookup(key)
+	if e == nil {
+		return result, nil
+	}
+	if e.hash == nil {
+		return nil, errWrongType
+	}
+	for k, v := range e.hash {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (m *MemoryKV) HDel(ctx context.Context, key string, fields ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return nil
+	}
+	if e.hash == nil {
+		return errWrongType
+	}
+	for _, f := range fields {
+		delete(e.hash, f)
+	}
+	// 与 Redis 一致，哈希为空时删除整个键
+	if len(e.hash) == 0 {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Scan 按写入顺序遍历，游标为下一个要返回的写入序号。遍历期间删除键不会导致其他键被跳过；
+// 新写入或被覆盖的键可能被返回，也可能被返回两次（与 Redis SCAN 的保证一致）
+func (m *MemoryKV) Scan(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	if count <= 0 {
+		count = 10
+	}
+
+	type scanItem struct {
+		key string
+		seq uint64
+	}
+	m.mu.Lock()
+	now := time.Now()
+	var items []scanItem
+	for k, e := range m.entries {
+		if e.seq >= cursor && !e.expired(now) {
+			items = append(items, scanItem{key: k, seq: e.seq})
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(items, func(i, j int) bool { return items[i].seq < items[j].seq })
+
+	var next uint64
+	if int64(len(items)) > count {
+		items = items[:count]
+		next = items[count-1].seq + 1
+	}
+
+	var matched []string
+	for _, item := range items {
+		if globMatch(pattern, item.key) {
+			matched = append(matched, item.key)
+		}
+	}
+	return matched, next, nil
+}
+
+// globMatch 按 Redis KEYS/SCAN 的规则匹配键：* 匹配任意个字符（包括 /），? 匹配一个字符，
+// [abc]、[^a-z] 匹配字符集合，\ 转义下一个字符。与 Redis 一样按字节匹配，不完整的模式不报错
+func globMatch(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if globMatch(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			var ok bool
+			ok, pattern = matchClass(pattern[1:], key[0])
+			if !ok {
+				return false
+			}
+			key = key[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// matchClass 匹配 [ 之后的字符集合，返回是否匹配以及 ] 之后剩余的模式
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	// 与 Redis 一致，缺少 ] 时把模式末尾视为集合结束
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return matched != negate, pattern
+}
+
+func (m *MemoryKV) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if e := m.lookup(k); e != nil && e.hash == nil {
+			result[k] = e.value
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryKV) SetMany(ctx context.Context, values map[string]string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+	for k, v := range values {
+		m.put(k, &memoryEntry{value: v, expiresAt: expiresAt})
+	}
+	return nil
+}
+
+func (m *MemoryKV) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryKV) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
+
+func (m *MemoryKV) Driver() string {
+	return "memory"
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestMemoryKV(t *testing.T) *MemoryKV {
+	t.Helper()
+	m := NewMemoryKV()
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "session:1/abc", true},
+		{"session:*", "session:1/abc", true},
+		{"session:*:index", "session:1/2:index", true},
+		{"*/abc", "session:1/abc", true},
+		{"session:*", "sessions", false},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", true},
+		{"a?c", "ac", false},
+		{"a**b", "axyzb", true},
+		{"[abc]x", "bx", true},
+		{"[abc]x", "dx", false},
+		{"[^abc]x", "dx", true},
+		{"[^abc]x", "ax", false},
+		{"[a-c]x", "cx", true},
+		{"[c-a]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{`[\]]`, "]", true},
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		// 与 Redis 一致，不完整的模式不报错
+		{"[abc", "a", true},
+		{`abc\`, `abc\`, true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryKVTTL(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMemoryKV(t)
+
+	if ttl, _ := m.TTL(ctx, "missing"); ttl != TTLNotExist {
+		t.Errorf("TTL(missing) = %v, want %v", ttl, TTLNotExist)
+	}
+	m.Set(ctx, "forever", "v", 0)
+	if ttl, _ := m.TTL(ctx, "forever"); ttl != TTLNoExpire {
+		t.Errorf("TTL(forever) = %v, want %v", ttl, TTLNoExpire)
+	}
+	m.Set(ctx, "short", "v", time.Minute)
+	if ttl, _ := m.TTL(ctx, "short"); ttl != time.Minute {
+		t.Errorf("TTL(short) = %v, want %v", ttl, time.Minute)
+	}
+
+	m.Set(ctx, "expiring", "v", 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := m.Get(ctx, "expiring"); !errors.Is(err, ErrNil) {
+		t.Errorf("Get(expiring) error = %v, want ErrNil", err)
+	}
+	if ok, _ := m.Exists(ctx, "expiring"); ok {
+		t.Error("Exists(expiring) = true after expiration")
+	}
+
+	// Expire 传入非正数时与 Redis 一致，立即删除键
+	m.Expire(ctx, "forever", 0)
+	if ok, _ := m.Exists(ctx, "forever"); ok {
+		t.Error("Exists(forever) = true after Expire(0)")
+	}
+}
+
+func TestMemoryKVIncr(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMemoryKV(t)
+
+	for want := int64(1); want <= 3; want++ {
+		if n, err := m.Incr(ctx, "counter"); err != nil || n != want {
+			t.Fatalf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	if n, _ := m.Decr(ctx, "counter"); n != 2 {
+		t.Errorf("Decr = %d, want 2", n)
+	}
+
+	// 自增保留原有的过期时间
+	m.Set(ctx, "limited", "5", time.Minute)
+	if n, _ := m.Incr(ctx, "limited"); n != 6 {
+		t.Errorf("Incr(limited) = %d, want 6", n)
+	}
+	if ttl, _ := m.TTL(ctx, "limited"); ttl != time.Minute {
+		t.Errorf("TTL(limited) = %v, want %v", ttl, time.Minute)
+	}
+
+	m.Set(ctx, "text", "abc", 0)
+	if _, err := m.Incr(ctx, "text"); err == nil {
+		t.Error("Incr(text) error = nil, want error")
+	}
+	m.HSet(ctx, "hash", "f", "1")
+	if _, err := m.Incr(ctx, "hash"); !errors.Is(err, errWrongType) {
+		t.Errorf("Incr(hash) error = %v, want errWrongType", err)
+	}
+}
+
+func TestMemoryKVIncrWithTTL(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMemoryKV(t)
+
+	if n, err := m.IncrWithTTL(ctx, "window", time.Minute); err != nil || n != 1 {
+		t.Fatalf("IncrWithTTL = %d, %v, want 1", n, err)
+	}
+	if ttl, _ := m.TTL(ctx, "window"); ttl != time.Minute {
+		t.Errorf("TTL after first IncrWithTTL = %v, want %v", ttl, time.Minute)
+	}
+	// 已有过期时间时不延长窗口
+	if n, _ := m.IncrWithTTL(ctx, "window", time.Hour); n != 2 {
+		t.Errorf("IncrWithTTL = %d, want 2", n)
+	}
+	if ttl, _ := m.TTL(ctx, "window"); ttl != time.Minute {
+		t.Errorf("TTL after second IncrWithTTL = %v, want %v", ttl, time.Minute)
+	}
+
+	// 之前只自增、没有设置过期时间的计数器会补上过期时间
+	m.Incr(ctx, "stuck")
+	if n, _ := m.IncrWithTTL(ctx, "stuck", time.Minute); n != 2 {
+		t.Errorf("IncrWithTTL(stuck) = %d, want 2", n)
+	}
+	if ttl, _ := m.TTL(ctx, "stuck"); ttl != time.Minute {
+		t.Errorf("TTL(stuck) = %v, want %v", ttl, time.Minute)
+	}
+
+	m.HSet(ctx, "hash", "f", "1")
+	if _, err := m.IncrWithTTL(ctx, "hash", time.Minute); !errors.Is(err, errWrongType) {
+		t.Errorf("IncrWithTTL(hash) error = %v, want errWrongType", err)
+	}
+}
+
+func TestMemoryKVScan(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMemoryKV(t)
+
+	keys := []string{"session:1:a", "session:1:b", "session:2/x", "sessions", "user:1", "user:2"}
+	for _, k := range keys {
+		m.Set(ctx, k, "v", 0)
+	}
+	m.Set(ctx, "session:expired", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"session:*", []string{"session:1:a", "session:1:b", "session:2/x"}},
+		{"session:?:*", []string{"session:1:a", "session:1:b"}},
+		{"*/x", []string{"session:2/x"}},
+		{"user:[12]", []string{"user:1", "user:2"}},
+		{"", keys},
+	}
+	for _, tt := range tests {
+		var got []string
+		var cursor uint64
+		for {
+			// 每批只取两个键，确认游标能遍历完全部的键
+			batch, next, err := m.Scan(ctx, cursor, tt.pattern, 2)
+			if err != nil {
+				t.Fatalf("Scan(%q) error = %v", tt.pattern, err)
+			}
+			got = append(got, batch...)
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		sort.Strings(got)
+		if len(got) != len(tt.want) {
+			t.Errorf("Scan(%q) = %v, want %v", tt.pattern, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Scan(%q) = %v, want %v", tt.pattern, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMemoryKVScanWhileDeleting(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMemoryKV(t)
+
+	const total = 25
+	for i := 0; i < total; i++ {
+		m.Set(ctx, "key:"+strconv.Itoa(i), "v", 0)
+	}
+
+	// 边遍历边删除，与 DeleteByPattern 的用法相同，删除已返回的键不能导致其他键被跳过
+	seen := 0
+	var cursor uint64
+	for {
+		batch, next, err := m.Scan(ctx, cursor, "key:*", 4)
+		if err != nil {
+			t.Fatalf("Scan error = %v", err)
+		}
+		seen += len(batch)
+		m.Delete(ctx, batch...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	if seen != total {
+		t.Errorf("Scan returned %d keys, want %d", seen, total)
+	}
+}
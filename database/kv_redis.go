@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisKV 基于 Redis 的 KV 实现
+// 所有键都会自动加上 prefix，多个环境共用一个 Redis 时互不干扰；
+// 对调用方透明：传入和返回的都是不带前缀的键
+type RedisKV struct {
+	client *redis.Client
+	prefix string
+}
+
+// pipelineBatch 单个 pipeline 中最多包含的命令数
+const pipelineBatch = 500
+
+var _ KV = (*RedisKV)(nil)
+
+// NewRedisKV 使用已连接的客户端创建 KV，prefix 为空表示不加前缀
+func NewRedisKV(client *redis.Client, prefix string) *RedisKV {
+	return &RedisKV{client: client, prefix: prefix}
+}
+
+// k 为键加上前缀
+func (r *RedisKV) k(key string) string {
+	return r.prefix + key
+}
+
+// ks 为一组键加上前缀
+func (r *RedisKV) ks(keys []string) []string {
+	out := make([]string, len(keys))
+	for i, key := range keys {
+		out[i] = r.prefix + key
+	}
+	return out
+}
+
+// convertNil 将 redis.Nil 统一转换为 ErrNil
+func convertNil(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrNil
+	}
+	return err
+}
+
+func (r *RedisKV) Get(ctx context.Context, key string) (string, error) {
+	v, err := r.client.Get(ctx, r.k(key)).Result()
+	return v, convertNil(err)
+}
+
+func (r *RedisKV) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	return r.client.Set(ctx, r.k(key), value, expiration).Err()
+}
+
+func (r *RedisKV) Delete(ctx context.Context, keys ...string) error {
+	// 键较多时分批删除，避免单条命令过大
+	for start, end := range batches(len(keys)) {
+		if err := r.client.Del(ctx, r.ks(keys[start:end])...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RedisKV) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.Exists(ctx, r.k(key)).Result()
+	return n > 0, err
+}
+
+func (r *RedisKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	d, err := r.client.TTL(ctx, r.k(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis 对 -1/-2 返回 time.Duration(-1)/(-2)，这里统一成常量
+	switch d {
+	case -1:
+		return TTLNoExpire, nil
+	case -2:
+		return TTLNotExist, nil
+	}
+	return d, nil
+}
+
+func (r *RedisKV) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return r.client.Expire(ctx, r.k(key), expiration).Err()
+}
+
+func (r *RedisKV) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, r.k(key)).Result()
+}
+
+// incrWithTTLScript 自增后检查过期时间，没有过期时间（TTL 为 -1）时设置，整个脚本在 Redis 中原子执行
+var incrWithTTLScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if tonumber(ARGV[1]) > 0 and redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (r *RedisKV) IncrWithTTL(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return incrWithTTLScript.Run(ctx, r.client, []string{r.k(key)}, expiration.Milliseconds()).Int64()
+}
+
+func (r *RedisKV) Decr(ctx context.Context, key string) (int64, error) {
+	return r.client.Decr(ctx, r.k(key)).Result()
+}
+
+func (r *RedisKV) HSet(ctx context.Context, key, field, value string) error {
+	return r.client.HSet(ctx, r.k(key), field, value).Err()
+}
+
+func (r *RedisKV) HGet(ctx context.Context, key, field string) (string, error) {
+	v, err := r.client.HGet(ctx, r.k(key), field).Result()
+	return v, convertNil(err)
+}
+
+func (r *RedisKV) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, r.k(key)).Result()
+}
+
+func (r *RedisKV) HDel(ctx context.Context, key string, fields ...string) error {
+	return r.client.HDel(ctx, r.k(key), fields...).Err()
+}
+
+// escapeGlob 转义 Redis 匹配模式中的特殊字符（规则与 globMatch 相同），使 s 只匹配其字面值
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func (r *RedisKV) Scan(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	// 前缀按字面值匹配，否则包含 * 等字符的前缀会匹配到其他环境的键
+	keys, next, err := r.client.Scan(ctx, cursor, escapeGlob(r.prefix)+pattern, count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, r.prefix)
+	}
+	return keys, next, nil
+}
+
+func (r *RedisKV) GetMany(ctx context.Context, keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	for start, end := range batches(len(keys)) {
+		vals, err := r.client.MGet(ctx, r.ks(keys[start:end])...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			if s, ok := v.(string); ok {
+				result[keys[start+i]] = s
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *RedisKV) SetMany(ctx context.Context, values map[string]string, expiration time.Duration) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	for start, end := range batches(len(keys)) {
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys[start:end] {
+				pipe.Set(ctx, r.k(key), values[key], expiration)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batches 将 n 个元素按 pipelineBatch 切分，依次产出每批的 [start, end)
+func batches(n int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for start := 0; start < n; start += pipelineBatch {
+			end := min(start+pipelineBatch, n)
+			if !yield(start, end) {
+				return
+			}
+		}
+	}
+}
+
+func (r *RedisKV) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisKV) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisKV) Driver() string {
+	return "redis"
+}
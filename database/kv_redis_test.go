@@ -0,0 +1,26 @@
+package database
+
+import "testing"
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   bool
+	}{
+		{"blog:prod:", "blog:prod:session:1", true},
+		{"blog:*:", "blog:*:session:1", true},
+		{"blog:*:", "blog:staging:session:1", false},
+		{"blog?:", "blog1:session:1", false},
+		{"[ab]:", "[ab]:session:1", true},
+		{"[ab]:", "a:session:1", false},
+		{`a\b:`, `a\b:session:1`, true},
+		{`a\b:`, `ab:session:1`, false},
+	}
+	for _, tt := range tests {
+		pattern := escapeGlob(tt.prefix) + "session:*"
+		if got := globMatch(pattern, tt.key); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", pattern, tt.key, got, tt.want)
+		}
+	}
+}
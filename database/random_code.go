@@ -1,8 +1,9 @@
 package database
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"fmt"
+	"math/big"
 )
 
 const (
@@ -14,10 +15,9 @@ const (
 	MixedChars = NumberChars + LetterChars
 )
 
-var seededRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
-
 // GenerateMixedCode 生成数字+字母验证码（项目唯一导出验证码生成器）
 // 保留此函数供全项目使用，移除其他不需要的导出函数以减少混淆。
+// 用于注册/邮箱验证码、OAuth state 和随机密码，必须使用 crypto/rand，不能被预测
 func GenerateMixedCode(length int) string {
 	return generateRandomCode(length, MixedChars)
 }
@@ -25,8 +25,14 @@ func GenerateMixedCode(length int) string {
 // generateRandomCode 内部生成随机码函数
 func generateRandomCode(length int, charset string) string {
 	b := make([]byte, length)
+	limit := big.NewInt(int64(len(charset)))
 	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			// 系统随机源不可用时不能退回到可预测的随机数
+			panic(fmt.Sprintf("读取系统随机数失败: %v", err))
+		}
+		b[i] = charset[n.Int64()]
 	}
 	return string(b)
 }
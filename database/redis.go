@@ -1,8 +1,11 @@
 package database
 
 import (
+	"blog/config"
+	"blog/metrics"
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -11,13 +14,42 @@ import (
 var RedisClient *redis.Client
 var ctx = context.Background()
 
+// InitKV 根据配置 kv.driver 初始化全局键值存储
+// redis: 连接 Redis，失败时返回错误；memory: 使用进程内存，无需 Redis
+func InitKV() error {
+	switch config.Cfg.KV.Driver {
+	case config.KVDriverMemory:
+		Store = NewMemoryKV()
+		slog.Warn("使用内存KV存储，数据不会持久化，且不支持多实例部署")
+		return nil
+	default:
+		if err := InitRedis(); err != nil {
+			return err
+		}
+		Store = NewRedisKV(RedisClient, config.Cfg.Redis.KeyPrefix)
+		return nil
+	}
+}
+
+// CloseKV 关闭全局键值存储
+func CloseKV() error {
+	if Store != nil {
+		return Store.Close()
+	}
+	return nil
+}
+
 // InitRedis 初始化Redis连接
 func InitRedis() error {
+	cfg := config.Cfg.Redis
 	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     "127.0.0.1:6379",
-		Password: "",
-		DB:       0,
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
+	if config.Cfg.Metrics.Enabled {
+		RedisClient.AddHook(metrics.RedisHook{})
+	}
 
 	// 测试连接
 	_, err := RedisClient.Ping(ctx).Result()
@@ -25,7 +57,7 @@ func InitRedis() error {
 		return fmt.Errorf("redis连接失败: %v", err)
 	}
 
-	fmt.Println("Redis连接成功")
+	slog.Info("Redis连接成功", "addr", cfg.Addr)
 	return nil
 }
 
@@ -37,99 +69,173 @@ func CloseRedis() error {
 	return nil
 }
 
+// 以下辅助函数统一通过 Store 访问，底层可以是 Redis 或内存实现
+
+// errStoreNotReady KV存储未初始化
+var errStoreNotReady = fmt.Errorf("KV存储未初始化")
+
 // SetString 设置字符串值
 func SetString(key, value string, expiration time.Duration) error {
-	if RedisClient == nil {
-		return fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return errStoreNotReady
 	}
-	return RedisClient.Set(ctx, key, value, expiration).Err()
+	return Store.Set(ctx, key, value, expiration)
 }
 
-// GetString 获取字符串值
+// GetString 获取字符串值，键不存在时返回 ErrNil
 func GetString(key string) (string, error) {
-	if RedisClient == nil {
-		return "", fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return "", errStoreNotReady
 	}
-	return RedisClient.Get(ctx, key).Result()
+	return Store.Get(ctx, key)
 }
 
 // Delete 删除键
 func Delete(key string) error {
-	if RedisClient == nil {
-		return fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return errStoreNotReady
 	}
-	return RedisClient.Del(ctx, key).Err()
+	return Store.Delete(ctx, key)
 }
 
 // Exists 检查键是否存在
 func Exists(key string) (bool, error) {
-	if RedisClient == nil {
-		return false, fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return false, errStoreNotReady
 	}
-	result, err := RedisClient.Exists(ctx, key).Result()
-	return result > 0, err
+	return Store.Exists(ctx, key)
 }
 
-// 👈 新增：GetTTL 获取键的剩余过期时间
+// GetTTL 获取键的剩余过期时间
 func GetTTL(key string) (time.Duration, error) {
-	if RedisClient == nil {
-		return 0, fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return 0, errStoreNotReady
 	}
-	return RedisClient.TTL(ctx, key).Result()
+	return Store.TTL(ctx, key)
 }
 
-// 👈 新增：SetExpire 设置键的过期时间
+// SetExpire 设置键的过期时间
 func SetExpire(key string, expiration time.Duration) error {
-	if RedisClient == nil {
-		return fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return errStoreNotReady
 	}
-	return RedisClient.Expire(ctx, key, expiration).Err()
+	return Store.Expire(ctx, key, expiration)
 }
 
-// 👈 新增：GetKeys 获取匹配模式的所有键
-func GetKeys(pattern string) ([]string, error) {
-	if RedisClient == nil {
-		return nil, fmt.Errorf("Redis客户端未初始化")
+// scanCount 每次 SCAN 建议返回的键数量
+const scanCount = 200
+
+// ScanKeys 使用 SCAN 游标分批遍历匹配模式的键，不会像 KEYS 一样阻塞 Redis
+// fn 返回错误时停止遍历；同一个键可能被返回多次（SCAN 的语义），调用方需要能够容忍
+func ScanKeys(pattern string, fn func(keys []string) error) error {
+	if Store == nil {
+		return errStoreNotReady
+	}
+
+	var cursor uint64
+	for {
+		batch, next, err := Store.Scan(ctx, cursor, pattern, scanCount)
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
 	}
-	return RedisClient.Keys(ctx, pattern).Result()
 }
 
-// 👈 新增：Increment 递增计数器
+// DeleteByPattern 删除匹配模式的所有键，返回删除的数量
+func DeleteByPattern(pattern string) (int, error) {
+	count := 0
+	err := ScanKeys(pattern, func(batch []string) error {
+		count += len(batch)
+		return Store.Delete(ctx, batch...)
+	})
+	return count, err
+}
+
+// GetStrings 批量获取字符串值，结果中不包含不存在的键
+func GetStrings(keys []string) (map[string]string, error) {
+	if Store == nil {
+		return nil, errStoreNotReady
+	}
+	return Store.GetMany(ctx, keys)
+}
+
+// SetStrings 批量设置字符串值，使用相同的过期时间
+func SetStrings(values map[string]string, expiration time.Duration) error {
+	if Store == nil {
+		return errStoreNotReady
+	}
+	return Store.SetMany(ctx, values, expiration)
+}
+
+// DeleteKeys 批量删除键
+func DeleteKeys(keys ...string) error {
+	if Store == nil {
+		return errStoreNotReady
+	}
+	return Store.Delete(ctx, keys...)
+}
+
+// Increment 递增计数器
 func Increment(key string) (int64, error) {
-	if RedisClient == nil {
-		return 0, fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return 0, errStoreNotReady
+	}
+	return Store.Incr(ctx, key)
+}
+
+// IncrementWithTTL 递增计数器，键没有过期时间时原子地设置为 expiration，用于按时间窗口计数
+func IncrementWithTTL(key string, expiration time.Duration) (int64, error) {
+	if Store == nil {
+		return 0, errStoreNotReady
 	}
-	return RedisClient.Incr(ctx, key).Result()
+	return Store.IncrWithTTL(ctx, key, expiration)
 }
 
-// 👈 新增：Decrement 递减计数器
+// Decrement 递减计数器
 func Decrement(key string) (int64, error) {
-	if RedisClient == nil {
-		return 0, fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return 0, errStoreNotReady
 	}
-	return RedisClient.Decr(ctx, key).Result()
+	return Store.Decr(ctx, key)
 }
 
-// 👈 新增：SetHash 设置哈希字段
+// SetHash 设置哈希字段
 func SetHash(key, field, value string) error {
-	if RedisClient == nil {
-		return fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return errStoreNotReady
 	}
-	return RedisClient.HSet(ctx, key, field, value).Err()
+	return Store.HSet(ctx, key, field, value)
 }
 
-// 👈 新增：GetHash 获取哈希字段
+// GetHash 获取哈希字段
 func GetHash(key, field string) (string, error) {
-	if RedisClient == nil {
-		return "", fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return "", errStoreNotReady
 	}
-	return RedisClient.HGet(ctx, key, field).Result()
+	return Store.HGet(ctx, key, field)
 }
 
-// 👈 新增：GetAllHash 获取哈希的所有字段
+// GetAllHash 获取哈希的所有字段
 func GetAllHash(key string) (map[string]string, error) {
-	if RedisClient == nil {
-		return nil, fmt.Errorf("Redis客户端未初始化")
+	if Store == nil {
+		return nil, errStoreNotReady
+	}
+	return Store.HGetAll(ctx, key)
+}
+
+// DeleteHash 删除哈希字段
+func DeleteHash(key string, fields ...string) error {
+	if Store == nil {
+		return errStoreNotReady
 	}
-	return RedisClient.HGetAll(ctx, key).Result()
+	return Store.HDel(ctx, key, fields...)
 }
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeleteByPattern(t *testing.T) {
+	Store = newTestMemoryKV(t)
+	t.Cleanup(func() { Store = nil })
+
+	// 超过一批 SCAN 的数量，确认会遍历所有批次
+	for i := 0; i < scanCount+10; i++ {
+		SetString("mfa_totp_used:1:"+strconv.Itoa(i), "1", time.Minute)
+	}
+	SetString("mfa_totp_used:12:123456", "1", time.Minute)
+	SetString("mfa_attempts:1", "3", time.Minute)
+
+	count, err := DeleteByPattern("mfa_totp_used:1:*")
+	if err != nil {
+		t.Fatalf("DeleteByPattern error = %v", err)
+	}
+	if count != scanCount+10 {
+		t.Errorf("DeleteByPattern deleted %d keys, want %d", count, scanCount+10)
+	}
+	for _, key := range []string{"mfa_totp_used:12:123456", "mfa_attempts:1"} {
+		if ok, _ := Exists(key); !ok {
+			t.Errorf("key %s was deleted, want kept", key)
+		}
+	}
+	var left int
+	ScanKeys("mfa_totp_used:1:*", func(keys []string) error {
+		left += len(keys)
+		return nil
+	})
+	if left != 0 {
+		t.Errorf("%d keys left after DeleteByPattern", left)
+	}
+}
@@ -2,8 +2,9 @@ package database
 
 import (
 	"blog/Model"
+	"blog/logger"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -12,61 +13,35 @@ import (
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-// 创建sqlite数据库
-func InitSQLite() {
+// openSQLite 准备 SQLite 数据库文件并返回对应的驱动
+func openSQLite(dbFile string) gorm.Dialector {
 	// 使用绝对路径
-	dir, err := os.Getwd()
-	if err != nil {
-		log.Fatal("获取工作目录失败:", err)
+	if !filepath.IsAbs(dbFile) {
+		dir, err := os.Getwd()
+		if err != nil {
+			logger.Fatal("获取工作目录失败", "error", err)
+		}
+		dbFile = filepath.Join(dir, dbFile)
 	}
 	// 创建文件夹
-	dataDir := filepath.Join(dir, "data")
+	dataDir := filepath.Dir(dbFile)
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		log.Fatal("创建data目录失败:", err)
+		logger.Fatal("创建数据目录失败", "dir", dataDir, "error", err)
 	}
-	// 生成数据库文件路径
-	dbFile := filepath.Join(dataDir, "user.db")
-	fmt.Printf("数据库文件完整路径: %s\n", dbFile)
+	slog.Info("SQLite 数据库文件", "path", dbFile)
 
 	if err := testFilePermissions(dbFile); err != nil {
-		log.Fatal("文件权限测试失败:", err)
-	}
-
-	var openErr error
-	DB, openErr = gorm.Open(sqlite.Open(dbFile), &gorm.Config{})
-	if openErr != nil {
-		log.Printf("数据库连接详细错误: %v", openErr)
-		log.Printf("错误类型: %T", openErr)
-		panic("连接数据库失败，详细错误: " + openErr.Error())
-	}
-
-	// 自动迁移所有表结构
-	err = DB.AutoMigrate(
-		&Model.User{},
-		&Model.Tag{},
-		&Model.Content{},
-		&Model.FileRecord{},
-		&Model.ContentTag{},
-		&Model.ContentFile{},
-		&Model.Comment{},
-		&Model.EmailVerify{},
-		&Model.OAuthPlatform{},
-		&Model.OAuthAccount{},
-		&Model.OAuthState{},
-	)
-	if err != nil {
-		log.Fatal("自动迁移表结构失败:", err)
+		logger.Fatal("文件权限测试失败", "path", dbFile, "error", err)
 	}
 
-	initDefaultAdmin()
-
-	fmt.Println("成功连接到数据库并创建所有表结构")
+	return sqlite.Open(dbFile)
 }
 
-// initDefaultAdmin 首次运行时创建默认管理员用户
-func initDefaultAdmin() {
+// InitialAdminPasswordFile 首次运行时默认管理员初始密码的保存位置（相对路径基于工作目录）
+const InitialAdminPasswordFile = "data/initial_admin_password"
+
+// InitDefaultAdmin 首次运行时创建默认管理员用户（需在迁移完成后调用）
+func InitDefaultAdmin() {
 	var count int64
 	DB.Model(&Model.User{}).Count(&count)
 
@@ -79,7 +54,15 @@ func initDefaultAdmin() {
 	defaultPassword := GenerateMixedCode(12)
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(defaultPassword), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("⚠️ 创建默认管理员失败（密码加密错误）: %v\n", err)
+		slog.Error("创建默认管理员失败（密码加密错误）", "error", err)
+		return
+	}
+
+	// 密码写入只有当前用户可读的文件，不输出到标准错误（结构化日志也写在那里，会被日志收集系统保存）。
+	// 先写文件再建账号，写入失败时不创建无人知道密码的管理员
+	if err := writeInitialAdminPassword(defaultPassword); err != nil {
+		slog.Error("保存默认管理员初始密码失败，未创建默认管理员，请执行 user create-admin 手动创建",
+			"path", InitialAdminPasswordFile, "error", err)
 		return
 	}
 
@@ -87,15 +70,38 @@ func initDefaultAdmin() {
 		Username: "admin",
 		Password: string(hashedPassword),
 		Email:    "admin@localhost",
-		IsAdmin:  true,
+		Role:     Model.RoleAdmin,
 	}
 
 	if err := DB.Create(&admin).Error; err != nil {
-		log.Printf("⚠️ 创建默认管理员失败: %v\n", err)
+		os.Remove(InitialAdminPasswordFile)
+		slog.Error("创建默认管理员失败", "error", err)
 		return
 	}
 
-	fmt.Println("✅ 已创建默认管理员账号 —— 用户名: admin  密码: admin123456  ⚠️ 请尽快修改密码！")
+	slog.Warn("已创建默认管理员账号，初始密码已保存到文件，请登录后尽快修改密码并删除该文件",
+		"username", admin.Username, "path", InitialAdminPasswordFile)
+}
+
+// writeInitialAdminPassword 以 0600 权限写入初始密码文件。
+// 已存在的旧文件先删除再重新创建，保证权限不沿用旧文件的设置
+func writeInitialAdminPassword(password string) error {
+	if err := os.MkdirAll(filepath.Dir(InitialAdminPasswordFile), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(InitialAdminPasswordFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, err := os.OpenFile(InitialAdminPasswordFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(file, password); err != nil {
+		file.Close()
+		os.Remove(InitialAdminPasswordFile)
+		return err
+	}
+	return file.Close()
 }
 
 // 测试文件权限
@@ -113,6 +119,6 @@ func testFilePermissions(dbFile string) error {
 		return fmt.Errorf("无法删除测试文件: %v", err)
 	}
 
-	fmt.Println("文件权限测试通过")
+	slog.Debug("文件权限测试通过", "path", dbFile)
 	return nil
 }
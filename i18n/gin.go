@@ -0,0 +1,49 @@
+package i18n
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// QueryLanguage 显式指定语言的查询参数，优先级最高
+	QueryLanguage = "lang"
+
+	contextKey  = "language"
+	explicitKey = "language_explicit"
+)
+
+// Middleware 协商本次请求使用的语言：?lang= 参数 > 用户偏好（登录后设置）> Accept-Language > 默认语言
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if lang := Normalize(c.Query(QueryLanguage)); lang != "" {
+			c.Set(explicitKey, true)
+			setLanguage(c, lang)
+		} else {
+			setLanguage(c, Negotiate(c.GetHeader("Accept-Language")))
+		}
+		c.Next()
+	}
+}
+
+// SetUserPreference 使用用户保存的语言偏好，请求中通过 ?lang= 显式指定时不覆盖
+func SetUserPreference(c *gin.Context, lang string) {
+	if c.GetBool(explicitKey) {
+		return
+	}
+	if lang = Normalize(lang); lang != "" {
+		setLanguage(c, lang)
+	}
+}
+
+func setLanguage(c *gin.Context, lang string) {
+	c.Set(contextKey, lang)
+	c.Header("Content-Language", lang)
+}
+
+// FromGin 返回本次请求的语言，未经过 Middleware 时返回默认语言
+func FromGin(c *gin.Context) string {
+	if lang := c.GetString(contextKey); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
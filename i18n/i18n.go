@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的语言
+const (
+	ZhCN = "zh-CN"
+	En   = "en"
+
+	// DefaultLanguage 找不到译文时最终回退的语言
+	DefaultLanguage = ZhCN
+)
+
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+// bundles 语言 -> 消息 key -> 译文
+var bundles = map[string]map[string]string{}
+
+func init() {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: 解析 %s 失败: %v", e.Name(), err))
+		}
+		bundles[strings.TrimSuffix(e.Name(), ".yaml")] = messages
+	}
+	if _, ok := bundles[DefaultLanguage]; !ok {
+		panic("i18n: 缺少默认语言 " + DefaultLanguage)
+	}
+}
+
+// Supported 返回所有支持的语言
+func Supported() []string {
+	langs := make([]string, 0, len(bundles))
+	for lang := range bundles {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize 将语言标签规范化为支持的语言，例如 en-US -> en、zh -> zh-CN，不支持时返回空字符串
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" {
+		return ""
+	}
+	for lang := range bundles {
+		if strings.EqualFold(lang, tag) {
+			return lang
+		}
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	for _, lang := range Supported() {
+		langBase, _, _ := strings.Cut(lang, "-")
+		if strings.EqualFold(langBase, base) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// Negotiate 根据 Accept-Language 选择语言，没有可用语言时返回 DefaultLanguage
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if lang := Normalize(c.tag); lang != "" {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// Lookup 查找译文：先找指定语言，再回退到默认语言
+func Lookup(lang, key string) (string, bool) {
+	if msg, ok := bundles[Normalize(lang)][key]; ok {
+		return msg, true
+	}
+	msg, ok := bundles[DefaultLanguage][key]
+	return msg, ok
+}
+
+// T 翻译消息，args 非空时按 fmt.Sprintf 格式化；找不到译文时返回 key 本身
+func T(lang, key string, args ...interface{}) string {
+	msg, ok := Lookup(lang, key)
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Missing 返回 lang 中缺少（相对默认语言）的 key，用于检查翻译是否完整
+func Missing(lang string) []string {
+	var missing []string
+	for key := range bundles[DefaultLanguage] {
+		if _, ok := bundles[lang][key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
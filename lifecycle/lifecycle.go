@@ -0,0 +1,105 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Hook 关闭时执行的钩子
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+var (
+	mu       sync.Mutex
+	hooks    []Hook
+	draining bool
+)
+
+// OnShutdown 注册关闭钩子，关闭时按注册的相反顺序执行（后注册的先关闭）
+// 后台任务应在这里注册自己的停止函数
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, Hook{Name: name, Fn: fn})
+}
+
+// Draining 是否正在关闭，关闭期间不应再接收新的长耗时任务
+func Draining() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return draining
+}
+
+// BeginShutdown 标记进入关闭流程
+func BeginShutdown() {
+	mu.Lock()
+	defer mu.Unlock()
+	draining = true
+}
+
+// Shutdown 倒序执行所有钩子，单个钩子失败不影响其他钩子，最后汇总错误
+func Shutdown(ctx context.Context) error {
+	BeginShutdown()
+
+	mu.Lock()
+	list := make([]Hook, len(hooks))
+	copy(list, hooks)
+	hooks = nil
+	mu.Unlock()
+
+	var errs []error
+	for i := len(list) - 1; i >= 0; i-- {
+		h := list[i]
+		if err := h.Fn(ctx); err != nil {
+			slog.Error("关闭失败", "component", h.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.Name, err))
+			continue
+		}
+		slog.Info("已关闭", "component", h.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// Tracker 跟踪进行中的任务（如文件上传），关闭时等待其完成
+type Tracker struct {
+	wg sync.WaitGroup
+}
+
+// Begin 开始一个任务，正在关闭时返回 false，调用方应拒绝请求
+// 返回 true 时必须调用 Done
+func (t *Tracker) Begin() bool {
+	// 与 BeginShutdown 共用锁，保证进入关闭流程后不会再有新的 Add
+	mu.Lock()
+	defer mu.Unlock()
+	if draining {
+		return false
+	}
+	t.wg.Add(1)
+	return true
+}
+
+// Done 结束一个任务
+func (t *Tracker) Done() {
+	t.wg.Done()
+}
+
+// Wait 等待所有任务完成或 ctx 超时
+func (t *Tracker) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
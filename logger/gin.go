@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"blog/constants"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID 请求ID响应头/请求头
+const HeaderRequestID = "X-Request-ID"
+
+// 只接受客户端传入的安全字符，避免日志注入
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware 读取或生成 X-Request-ID，写入响应头和请求 context
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware 结构化访问日志，替代 gin.Logger
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		slog.Log(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// RecoveryMiddleware 捕获 panic 并记录结构化日志，替代 gin.Recovery
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			slog.Any("error", err),
+			slog.String("path", c.Request.URL.Path),
+		)
+		c.Abort()
+		constants.SendResponse(c, constants.SystemError, nil)
+	})
+}
+
+// FromGin 返回绑定当前请求 context 的日志记录器
+// 记录的每条日志都会带上 request_id 和 user_id
+func FromGin(c *gin.Context) *slog.Logger {
+	return FromContext(c.Request.Context())
+}
+
+// FromContext 返回绑定 ctx 的日志记录器
+func FromContext(ctx context.Context) *slog.Logger {
+	return slog.New(ctxBound{Handler: slog.Default().Handler(), ctx: ctx})
+}
+
+// ctxBound 将固定的 context 传给下层 Handler，使不带 Context 后缀的调用也能附加请求字段
+type ctxBound struct {
+	slog.Handler
+	ctx context.Context
+}
+
+func (h ctxBound) Handle(_ context.Context, r slog.Record) error {
+	return h.Handler.Handle(h.ctx, r)
+}
+
+func (h ctxBound) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ctxBound{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h ctxBound) WithGroup(name string) slog.Handler {
+	return ctxBound{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Init 初始化全局结构化日志，同时接管标准库 log 的输出
+// format: json / text，level: debug / info / warn / error
+func Init(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("日志级别无效: %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("日志格式无效: %q（可选 json/text）", format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: h}))
+	return nil
+}
+
+// Fatal 记录错误日志并退出进程
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type fieldsKey struct{}
+
+// requestFields 请求级别的日志字段，由中间件放入请求的 context
+type requestFields struct {
+	RequestID string
+	UserID    int64
+}
+
+// WithRequestID 返回携带请求ID的 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &requestFields{RequestID: requestID})
+}
+
+// SetUserID 在已携带请求ID的 context 上记录当前登录用户
+func SetUserID(ctx context.Context, userID int64) {
+	if f, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		f.UserID = userID
+	}
+}
+
+// RequestID 从 context 中取出请求ID
+func RequestID(ctx context.Context) string {
+	if f, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		return f.RequestID
+	}
+	return ""
+}
+
+// contextHandler 为每条带 context 的日志自动附加 request_id 和 user_id
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*requestFields); ok {
+		r.AddAttrs(slog.String("request_id", f.RequestID))
+		if f.UserID != 0 {
+			r.AddAttrs(slog.Int64("user_id", f.UserID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
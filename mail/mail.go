@@ -0,0 +1,181 @@
+// Package mail 邮件发送：按配置选择发送方式（SMTP / 本地 outbox / 标准输出），
+// 使用内置模板渲染 HTML 和纯文本两种正文，并将每次发送记录到 email_verifies 表
+package mail
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/database"
+	"blog/lifecycle"
+	"blog/metrics"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Message 一封待发送的邮件
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string // 纯文本正文
+	HTML    string // HTML 正文，为空时只发送纯文本
+}
+
+// Transport 邮件发送方式
+type Transport interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewTransport 按配置创建发送方式
+func NewTransport(cfg config.MailConfig) (Transport, error) {
+	switch cfg.Transport {
+	case config.MailTransportSMTP:
+		return &smtpTransport{cfg: cfg.SMTP}, nil
+	case config.MailTransportFile:
+		return &fileTransport{dir: cfg.OutboxDir}, nil
+	case config.MailTransportStdout:
+		return &stdoutTransport{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件发送方式: %s", cfg.Transport)
+	}
+}
+
+// ErrSendFailed 邮件未能送出（发送方式返回错误），模板渲染等内部错误不属于此类
+var ErrSendFailed = errors.New("发送邮件失败")
+
+// ErrShuttingDown 服务正在关闭，SendAsync 不再接受新的邮件
+var ErrShuttingDown = errors.New("服务正在关闭")
+
+// 投递记录的状态
+const (
+	StatusSent   = "sent"
+	StatusFailed = "failed"
+)
+
+// Request 一次模板邮件的发送请求
+type Request struct {
+	To       string
+	Template string         // 模板名，见 templates 目录
+	Lang     string         // 邮件语言，为空时使用默认语言
+	Data     map[string]any // 模板数据
+	// Purpose 投递记录中的用途，与验证码用途一致（verify、password_reset 等）
+	Purpose string
+	// Code 邮件携带的验证码，投递记录只保存其哈希，用于之后标记为已使用
+	Code      string
+	ExpiresAt time.Time
+	// OnFailure SendAsync 发送失败后调用（在后台协程中），例如删除未送达的验证码；Send 不使用
+	OnFailure func(err error)
+}
+
+// Send 渲染模板并发送，无论成功与否都会写入投递记录
+func Send(ctx context.Context, req Request) error {
+	msg, err := Render(req.Template, req.Lang, req.Data)
+	if err != nil {
+		return err
+	}
+	msg.From = config.Cfg.Mail.From
+	msg.To = req.To
+
+	transport, err := NewTransport(config.Cfg.Mail)
+	if err != nil {
+		return err
+	}
+
+	sendErr := transport.Send(ctx, msg)
+	result := "success"
+	if sendErr != nil {
+		result = "failure"
+	}
+	metrics.EmailsSent.WithLabelValues(req.Template, result).Inc()
+	record(req, transport.Name(), sendErr)
+
+	if sendErr != nil {
+		return fmt.Errorf("%w: %v", ErrSendFailed, sendErr)
+	}
+	return nil
+}
+
+// Pending 后台发送中的邮件，服务关闭时等待其完成
+var Pending lifecycle.Tracker
+
+// asyncTimeout 后台发送单封邮件的最长时间
+const asyncTimeout = time.Minute
+
+// SendAsync 在后台发送邮件，用于不需要告知调用方结果的邮件（通知类邮件，以及不能让响应时间
+// 暴露邮箱是否注册的找回密码邮件）；服务正在关闭时直接丢弃，同样视为发送失败
+func SendAsync(req Request) {
+	if !Pending.Begin() {
+		slog.Warn("服务正在关闭，邮件未发送", "email", req.To, "template", req.Template)
+		if req.OnFailure != nil {
+			req.OnFailure(ErrShuttingDown)
+		}
+		return
+	}
+	go func() {
+		defer Pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), asyncTimeout)
+		defer cancel()
+		if err := Send(ctx, req); err != nil {
+			slog.Error("后台发送邮件失败", "email", req.To, "template", req.Template, "error", err)
+			if req.OnFailure != nil {
+				req.OnFailure(err)
+			}
+		}
+	}()
+}
+
+// record 写入投递记录；记录失败不影响发送结果，只记录日志
+func record(req Request, transport string, sendErr error) {
+	log := Model.EmailVerify{
+		Email:      req.To,
+		VerifyCode: HashCode(req.Code),
+		Purpose:    req.Purpose,
+		Status:     StatusSent,
+		Transport:  transport,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if log.Purpose == "" {
+		log.Purpose = req.Template
+	}
+	if log.ExpiresAt.IsZero() {
+		log.ExpiresAt = time.Now()
+	}
+	if sendErr != nil {
+		log.Status = StatusFailed
+		log.Error = truncate(sendErr.Error(), 500)
+	}
+	if err := database.DB.Create(&log).Error; err != nil {
+		slog.Error("写入邮件投递记录失败", "email", req.To, "template", req.Template, "error", err)
+	}
+}
+
+// HashCode 投递记录中验证码的哈希，没有验证码时为空
+func HashCode(code string) string {
+	if code == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// MarkUsed 验证码使用后将对应的投递记录标记为已使用
+func MarkUsed(purpose, email, code string) error {
+	return database.DB.Model(&Model.EmailVerify{}).
+		Where("LOWER(email) = ? AND purpose = ? AND verify_code = ? AND is_used = ?", strings.ToLower(strings.TrimSpace(email)), purpose, HashCode(code), false).
+		Update("is_used", true).Error
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"blog/config"
+	"blog/i18n"
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// 内置模板：每个模板包含 <name>.html 和 <name>.txt，HTML 模板套用 layout.html；
+// 文案放在 i18n 中（mail.<name>.*），主题为 mail.<name>.subject
+//
+//go:embed templates/*
+var templateFS embed.FS
+
+// 内置的模板名
+const (
+	TemplateVerification        = "verification"
+	TemplatePasswordReset       = "password_reset"
+	TemplateCommentNotification = "comment_notification"
+	TemplateAccountLocked       = "account_locked"
+)
+
+// Render 按语言渲染模板，返回填好主题和正文的邮件
+func Render(name, lang string, data map[string]any) (*Message, error) {
+	if lang = i18n.Normalize(lang); lang == "" {
+		lang = i18n.DefaultLanguage
+	}
+	vars := map[string]any{
+		"SiteURL": strings.TrimRight(config.Cfg.Mail.SiteURL, "/"),
+		"Lang":    lang,
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+	t := func(key string, args ...any) string { return i18n.T(lang, key, args...) }
+
+	textTmpl, err := texttemplate.New(name+".txt").
+		Funcs(texttemplate.FuncMap{"t": t}).
+		ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	if err := textTmpl.Execute(&text, vars); err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("layout.html").
+		Funcs(htmltemplate.FuncMap{"t": t}).
+		ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	if err := htmlTmpl.Execute(&html, vars); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: t("mail." + name + ".subject"),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,147 @@
+package mail
+
+import (
+	"blog/config"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// smtpTransport 通过 SMTP 服务器发送
+type smtpTransport struct {
+	cfg config.SMTPConfig
+}
+
+func (t *smtpTransport) Name() string { return config.MailTransportSMTP }
+
+func (t *smtpTransport) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if t.cfg.TLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: t.cfg.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	// 整个会话共用一个截止时间，避免服务器无响应时一直阻塞
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.cfg.TLS == "starttls" {
+		if err := client.StartTLS(&tls.Config{ServerName: t.cfg.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	if t.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// fileTransport 将邮件保存为 outbox 目录下的 .eml 文件，用于开发和测试
+type fileTransport struct {
+	dir string
+}
+
+func (t *fileTransport) Name() string { return config.MailTransportFile }
+
+func (t *fileTransport) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), sanitize(to.Address))
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0o644)
+}
+
+// stdoutTransport 将邮件输出到标准输出
+type stdoutTransport struct{}
+
+// stdoutMu 并发发送时避免多封邮件的内容交错
+var stdoutMu sync.Mutex
+
+func (t *stdoutTransport) Name() string { return config.MailTransportStdout }
+
+func (t *stdoutTransport) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	_, err = fmt.Fprintf(os.Stdout, "----- mail -----\r\n%s\r\n----- end -----\r\n", data)
+	return err
+}
+
+// sanitize 只保留文件名中安全的字符
+func sanitize(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_', c == '@':
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -1,32 +1,14 @@
 package main
 
 import (
-	"blog/AutoMigrate"
-	"blog/controller"
-	"blog/database"
-	"blog/security"
+	"blog/cli"
 	"log"
+	"os"
 )
 
 func main() {
-	// 初始化DB/Redis（修改 Init 函数以返回 error 更可靠）
-	database.InitSQLite()
-	database.InitRedis()
-
-	// 自动迁移
-	AutoMigrate.Generation_sql()
-
-	// 使用 controller 提供的引擎（已注册路由）
-	router := controller.InitializeServer()
-
-	// 可选：打印已注册路由用于调试
-	for _, r := range router.Routes() {
-		log.Printf("%s %s\n", r.Method, r.Path)
-	}
-
-	// 启动
-	security.SetupCORSMiddleware()
-	if err := router.Run(":18800"); err != nil {
+	// 解析子命令：serve（默认）、migrate、user、oauth、seed
+	if err := cli.Run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "blog"
+
+// Registry 独立的指标注册表，避免与第三方库的默认注册表冲突
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTP
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求数，按路由模板和状态码统计",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "正在处理的HTTP请求数",
+	})
+
+	// 数据库
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM 查询耗时",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "GORM 查询错误数（不含记录不存在）",
+	}, []string{"operation", "table"})
+
+	// Redis
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis 命令耗时",
+		Buckets:   []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"command"})
+
+	RedisCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_command_errors_total",
+		Help:      "Redis 命令错误数（不含键不存在）",
+	}, []string{"command"})
+
+	// 业务指标
+	Uploads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "文件上传次数",
+	}, []string{"kind", "result"})
+
+	UploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "成功上传的文件字节数",
+	}, []string{"kind"})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "登录次数",
+	}, []string{"method", "result"})
+
+	TokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "refresh token 换发次数，result 为 success / invalid / reused",
+	}, []string{"result"})
+
+	EmailsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_sent_total",
+		Help:      "邮件发送次数，按模板和结果（success / failure）统计",
+	}, []string{"template", "result"})
+
+	CommentsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "新建评论数",
+	})
+
+	// DeprecatedRequests 已弃用接口的调用次数，降到 0 后即可下线
+	DeprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deprecated_requests_total",
+		Help:      "已弃用接口的调用次数，按路由模板统计",
+	}, []string{"route"})
+
+	// RateLimited 被限流拒绝的请求数
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "被限流拒绝的请求数，按限流策略统计",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		DBQueryDuration,
+		DBQueryErrors,
+		RedisCommandDuration,
+		RedisCommandErrors,
+		Uploads,
+		UploadBytes,
+		Logins,
+		TokenRefreshes,
+		EmailsSent,
+		CommentsCreated,
+		DeprecatedRequests,
+		RateLimited,
+	)
+}
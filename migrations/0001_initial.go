@@ -0,0 +1,216 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为初始表结构的快照，之后的变更都由后续迁移完成。
+// 快照不能随 Model 修改，否则新库在 0001 中就会提前建出后续迁移才添加的列。
+// 关联字段只用于建外键：两边字段同名时 GORM 会把 belongs to 推断成 has one、把外键建反，
+// 因此这类外键改为在被引用的一方声明 has many
+
+type user0001 struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement"`
+	Username  string `gorm:"size:30;not null;uniqueIndex"`
+	Password  string `gorm:"size:100;not null"`
+	Email     string `gorm:"size:100;uniqueIndex"`
+	Avatar    string `gorm:"size:255"`
+	IsAdmin   bool   `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	OAuthAccounts []oauthAccount0001 `gorm:"foreignKey:UserID"`
+}
+
+func (user0001) TableName() string { return "users" }
+
+type tag0001 struct {
+	TagID        uint      `gorm:"primaryKey;autoIncrement"`
+	TagName      string    `gorm:"type:varchar(30);not null;uniqueIndex"`
+	TagAlias     string    `gorm:"type:varchar(30);default:''"`
+	Description  string    `gorm:"type:varchar(255);default:''"`
+	CreateTime   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdateTime   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	IsActive     bool      `gorm:"default:true"`
+	Icon         string    `gorm:"type:varchar(255);default:''"`
+	Color        string    `gorm:"type:varchar(50);default:''"`
+	DisplayOrder int       `gorm:"type:integer;default:0"`
+}
+
+func (tag0001) TableName() string { return "tags" }
+
+type content0001 struct {
+	ID                uint      `gorm:"primaryKey;autoIncrement"`
+	Title             string    `gorm:"type:varchar(255);not null"`
+	Content           string    `gorm:"type:text;not null"`
+	BriefIntroduction string    `gorm:"type:text"`
+	UserID            uint      `gorm:"not null"`
+	CoverImage        string    `gorm:"type:varchar(500)"`
+	Status            string    `gorm:"type:varchar(20);default:'draft';check:chk_contents_status,status IN ('draft','published','archived')"`
+	ViewCount         int       `gorm:"default:0"`
+	Likes             int       `gorm:"default:0"`
+	CommentCount      int       `gorm:"default:0"`
+	CreatedAt         time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt         time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	PublishedAt       *time.Time
+	ThumbnailURL      string `gorm:"type:varchar(500)"`
+
+	Comments     []comment0001     `gorm:"foreignKey:ContentID"`
+	ContentFiles []contentFile0001 `gorm:"foreignKey:ContentID"`
+}
+
+func (content0001) TableName() string { return "contents" }
+
+type fileRecord0001 struct {
+	FileID       uint      `gorm:"primaryKey;autoIncrement"`
+	OriginalName string    `gorm:"type:varchar(255);not null"`
+	StorageName  string    `gorm:"type:varchar(255);not null"`
+	FilePath     string    `gorm:"type:varchar(500);not null"`
+	FileURL      string    `gorm:"type:varchar(500);not null"`
+	FileSize     int64     `gorm:"not null"`
+	FileType     string    `gorm:"type:varchar(100)"`
+	UploadTime   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	Status       string    `gorm:"type:varchar(20);default:'active';check:chk_file_records_status,status IN ('active','deleted')"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	ContentFiles []contentFile0001 `gorm:"foreignKey:FileID"`
+}
+
+func (fileRecord0001) TableName() string { return "file_records" }
+
+type contentTag0001 struct {
+	ID        uint `gorm:"primaryKey;autoIncrement"`
+	ContentID uint `gorm:"not null;index"`
+	TagID     uint `gorm:"not null;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (contentTag0001) TableName() string { return "content_tags" }
+
+type contentFile0001 struct {
+	ContentID uint      `gorm:"primaryKey;not null"`
+	FileID    uint      `gorm:"primaryKey;not null"`
+	Order     int       `gorm:"default:0"`
+	Usage     string    `gorm:"type:varchar(50)"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (contentFile0001) TableName() string { return "content_files" }
+
+type comment0001 struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	CommentText string    `gorm:"type:text;not null"`
+	UserID      uint      `gorm:"not null"`
+	ContentID   uint      `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (comment0001) TableName() string { return "comments" }
+
+type emailVerify0001 struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	Email      string    `gorm:"type:varchar(255);not null"`
+	VerifyCode string    `gorm:"type:varchar(100);not null"`
+	Purpose    string    `gorm:"type:varchar(50);default:'registration'"`
+	IsUsed     bool      `gorm:"default:false"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ExpiresAt  time.Time
+}
+
+func (emailVerify0001) TableName() string { return "email_verifies" }
+
+type oauthPlatform0001 struct {
+	OAuthID      uint   `gorm:"primaryKey;autoIncrement"`
+	Platform     string `gorm:"size:50;not null;uniqueIndex"`
+	DisplayName  string `gorm:"size:100"`
+	ClientID     string `gorm:"size:255;not null"`
+	ClientSecret string `gorm:"size:255;not null"`
+	RedirectURL  string `gorm:"size:255;not null"`
+	AuthURL      string `gorm:"size:255"`
+	TokenURL     string `gorm:"size:255"`
+	UserInfoURL  string `gorm:"size:255"`
+	Scopes       string `gorm:"size:255"`
+	IconURL      string `gorm:"size:255"`
+	SortOrder    int    `gorm:"default:0"`
+	IsEnabled    bool   `gorm:"default:true"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (oauthPlatform0001) TableName() string { return "oauth_platforms" }
+
+type oauthAccount0001 struct {
+	ID                uint   `gorm:"primaryKey;autoIncrement"`
+	UserID            uint   `gorm:"not null;index"`
+	PlatformID        uint   `gorm:"not null;index"`
+	PlatformUserID    string `gorm:"size:100;not null;index:idx_platform_user,unique"`
+	PlatformUserName  string `gorm:"size:100"`
+	PlatformUserEmail string `gorm:"size:100"`
+	AvatarURL         string `gorm:"size:500"`
+	AccessToken       string `gorm:"size:500"`
+	RefreshToken      string `gorm:"size:500"`
+	TokenExpiresAt    *time.Time
+	RawData           string `gorm:"type:text"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	Platform oauthPlatform0001 `gorm:"foreignKey:PlatformID"`
+}
+
+func (oauthAccount0001) TableName() string { return "oauth_accounts" }
+
+type oauthState0001 struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	State      string    `gorm:"size:100;uniqueIndex;not null"`
+	UserID     uint      `gorm:"index"`
+	PlatformID uint      `gorm:"index"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+	CreatedAt  time.Time
+
+	Platform oauthPlatform0001 `gorm:"foreignKey:PlatformID"`
+}
+
+func (oauthState0001) TableName() string { return "oauth_states" }
+
+// 初始表结构
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := initialTables()
+			// 倒序删除，先删依赖其他表的表
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func initialTables() []interface{} {
+	return []interface{}{
+		&user0001{},
+		&tag0001{},
+		&content0001{},
+		&fileRecord0001{},
+		&contentTag0001{},
+		&contentFile0001{},
+		&comment0001{},
+		&emailVerify0001{},
+		&oauthPlatform0001{},
+		&oauthAccount0001{},
+		&oauthState0001{},
+	}
+}
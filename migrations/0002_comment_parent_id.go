@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// comment0002 迁移时的评论表快照，只包含本次变更涉及的字段
+type comment0002 struct {
+	ParentID *uint `gorm:"index:idx_comments_parent_id"`
+}
+
+func (comment0002) TableName() string {
+	return "comments"
+}
+
+// 评论增加 parent_id，用于回复评论
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "add_comment_parent_id",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			// 引入版本化迁移之前由 AutoMigrate 建的库可能已有该列，这里需要判断
+			if !m.HasColumn(&comment0002{}, "ParentID") {
+				if err := m.AddColumn(&comment0002{}, "ParentID"); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&comment0002{}, "idx_comments_parent_id") {
+				return m.CreateIndex(&comment0002{}, "idx_comments_parent_id")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasIndex(&comment0002{}, "idx_comments_parent_id") {
+				if err := m.DropIndex(&comment0002{}, "idx_comments_parent_id"); err != nil {
+					return err
+				}
+			}
+			if m.HasColumn(&comment0002{}, "ParentID") {
+				return dropColumn(tx, &comment0002{}, "ParentID")
+			}
+			return nil
+		},
+	})
+}
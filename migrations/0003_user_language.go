@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// user0003 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0003 struct {
+	Language string `gorm:"size:10;not null;default:''"`
+}
+
+func (user0003) TableName() string {
+	return "users"
+}
+
+// 用户增加 language，保存界面语言偏好
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "add_user_language",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasColumn(&user0003{}, "Language") {
+				return nil
+			}
+			return m.AddColumn(&user0003{}, "Language")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0003{}, "Language") {
+				return nil
+			}
+			return dropColumn(tx, &user0003{}, "Language")
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0004 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0004 struct {
+	BannedAt  *time.Time `gorm:"index"`
+	BanReason string     `gorm:"size:255;not null;default:''"`
+}
+
+func (user0004) TableName() string {
+	return "users"
+}
+
+// 用户增加 banned_at / ban_reason，支持管理员封禁账号
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "add_user_ban",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"BannedAt", "BanReason"} {
+				if m.HasColumn(&user0004{}, field) {
+					continue
+				}
+				if err := m.AddColumn(&user0004{}, field); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&user0004{}, "BannedAt") {
+				return m.CreateIndex(&user0004{}, "BannedAt")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasIndex(&user0004{}, "BannedAt") {
+				if err := m.DropIndex(&user0004{}, "BannedAt"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"BanReason", "BannedAt"} {
+				if !m.HasColumn(&user0004{}, field) {
+					continue
+				}
+				if err := dropColumn(tx, &user0004{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
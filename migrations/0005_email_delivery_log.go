@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// emailVerify0005 迁移时的邮件记录表快照，只包含本次变更涉及的字段
+type emailVerify0005 struct {
+	Email     string `gorm:"type:varchar(255);not null;index"`
+	Status    string `gorm:"type:varchar(20);not null;default:'sent'"`
+	Transport string `gorm:"type:varchar(20);not null;default:''"`
+	Error     string `gorm:"type:varchar(500);not null;default:''"`
+}
+
+func (emailVerify0005) TableName() string {
+	return "email_verifies"
+}
+
+// email_verifies 改为邮件投递记录：增加发送状态、发送方式和失败原因，按邮箱建索引
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "add_email_delivery_log",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"Status", "Transport", "Error"} {
+				if m.HasColumn(&emailVerify0005{}, field) {
+					continue
+				}
+				if err := m.AddColumn(&emailVerify0005{}, field); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&emailVerify0005{}, "Email") {
+				return m.CreateIndex(&emailVerify0005{}, "Email")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasIndex(&emailVerify0005{}, "Email") {
+				if err := m.DropIndex(&emailVerify0005{}, "Email"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"Error", "Transport", "Status"} {
+				if !m.HasColumn(&emailVerify0005{}, field) {
+					continue
+				}
+				if err := dropColumn(tx, &emailVerify0005{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0006 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0006 struct {
+	EmailVerifiedAt *time.Time
+}
+
+func (user0006) TableName() string {
+	return "users"
+}
+
+// 用户增加 email_verified_at；已有用户的邮箱从未验证过，保持为空
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "add_user_email_verified_at",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasColumn(&user0006{}, "EmailVerifiedAt") {
+				return nil
+			}
+			return m.AddColumn(&user0006{}, "EmailVerifiedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0006{}, "EmailVerifiedAt") {
+				return nil
+			}
+			return dropColumn(tx, &user0006{}, "EmailVerifiedAt")
+		},
+	})
+}
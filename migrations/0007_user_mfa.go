@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0007 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0007 struct {
+	TOTPSecret   string `gorm:"size:64;not null;default:''"`
+	MFAEnabledAt *time.Time
+}
+
+func (user0007) TableName() string {
+	return "users"
+}
+
+// mfaRecoveryCode0007 恢复码表快照
+type mfaRecoveryCode0007 struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (mfaRecoveryCode0007) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// 两步验证：用户增加 TOTP 密钥和启用时间，新增恢复码表
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "add_user_mfa",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, field := range []string{"TOTPSecret", "MFAEnabledAt"} {
+				if m.HasColumn(&user0007{}, field) {
+					continue
+				}
+				if err := m.AddColumn(&user0007{}, field); err != nil {
+					return err
+				}
+			}
+			return m.AutoMigrate(&mfaRecoveryCode0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if err := m.DropTable(&mfaRecoveryCode0007{}); err != nil {
+				return err
+			}
+			for _, field := range []string{"MFAEnabledAt", "TOTPSecret"} {
+				if !m.HasColumn(&user0007{}, field) {
+					continue
+				}
+				if err := dropColumn(tx, &user0007{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
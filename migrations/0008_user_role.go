@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// user0008 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0008 struct {
+	IsAdmin bool   `gorm:"not null;default:false"`
+	Role    string `gorm:"size:20;not null;default:'author';index"`
+}
+
+func (user0008) TableName() string {
+	return "users"
+}
+
+// is_admin 改为 role：管理员迁移为 admin，其他用户保持原有的权限（发表内容和评论），迁移为 author
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "replace_user_is_admin_with_role",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0008{}, "Role") {
+				if err := m.AddColumn(&user0008{}, "Role"); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&user0008{}, "Role") {
+				if err := m.CreateIndex(&user0008{}, "Role"); err != nil {
+					return err
+				}
+			}
+			if !m.HasColumn(&user0008{}, "IsAdmin") {
+				return nil
+			}
+			if err := tx.Model(&user0008{}).Where("is_admin = ?", true).
+				Update("role", "admin").Error; err != nil {
+				return err
+			}
+			return dropColumn(tx, &user0008{}, "IsAdmin")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0008{}, "IsAdmin") {
+				if err := m.AddColumn(&user0008{}, "IsAdmin"); err != nil {
+					return err
+				}
+			}
+			if !m.HasColumn(&user0008{}, "Role") {
+				return nil
+			}
+			if err := tx.Model(&user0008{}).Where("role = ?", "admin").
+				Update("is_admin", true).Error; err != nil {
+				return err
+			}
+			if m.HasIndex(&user0008{}, "Role") {
+				if err := m.DropIndex(&user0008{}, "Role"); err != nil {
+					return err
+				}
+			}
+			return dropColumn(tx, &user0008{}, "Role")
+		},
+	})
+}
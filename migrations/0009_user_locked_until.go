@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0009 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0009 struct {
+	LockedUntil *time.Time `gorm:"index"`
+}
+
+func (user0009) TableName() string {
+	return "users"
+}
+
+// 用户增加 locked_until，记录多次登录失败后的临时锁定
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "add_user_locked_until",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&user0009{}, "LockedUntil") {
+				if err := m.AddColumn(&user0009{}, "LockedUntil"); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&user0009{}, "LockedUntil") {
+				return m.CreateIndex(&user0009{}, "LockedUntil")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasIndex(&user0009{}, "LockedUntil") {
+				if err := m.DropIndex(&user0009{}, "LockedUntil"); err != nil {
+					return err
+				}
+			}
+			if !m.HasColumn(&user0009{}, "LockedUntil") {
+				return nil
+			}
+			return dropColumn(tx, &user0009{}, "LockedUntil")
+		},
+	})
+}
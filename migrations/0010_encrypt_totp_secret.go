@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"blog/secretbox"
+
+	"gorm.io/gorm"
+)
+
+// user0010 迁移时的用户表快照，只包含本次变更涉及的字段
+type user0010 struct {
+	UserID     uint   `gorm:"primaryKey"`
+	TOTPSecret string `gorm:"size:128;not null;default:''"`
+}
+
+func (user0010) TableName() string {
+	return "users"
+}
+
+// user0010Down 回滚后的 totp_secret 长度
+type user0010Down struct {
+	TOTPSecret string `gorm:"size:64;not null;default:''"`
+}
+
+func (user0010Down) TableName() string {
+	return "users"
+}
+
+// TOTP 密钥改为加密存储：加长 totp_secret 并加密已有的明文密钥
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "encrypt_user_totp_secret",
+		Up: func(tx *gorm.DB) error {
+			// SQLite 不限制 varchar 长度，AlterColumn 会重建整张表并丢失索引，因此跳过
+			if tx.Dialector.Name() != "sqlite" {
+				if err := tx.Migrator().AlterColumn(&user0010{}, "TOTPSecret"); err != nil {
+					return err
+				}
+			}
+			return convertTOTPSecrets(tx, func(value string) (string, error) {
+				if secretbox.IsSealed(value) {
+					return value, nil
+				}
+				return secretbox.Seal(value)
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := convertTOTPSecrets(tx, func(value string) (string, error) {
+				if !secretbox.IsSealed(value) {
+					return value, nil
+				}
+				return secretbox.Open(value)
+			}); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() != "sqlite" {
+				return tx.Migrator().AlterColumn(&user0010Down{}, "TOTPSecret")
+			}
+			return nil
+		},
+	})
+}
+
+// convertTOTPSecrets 逐个转换已绑定验证器的用户的 totp_secret
+func convertTOTPSecrets(tx *gorm.DB, convert func(string) (string, error)) error {
+	var users []user0010
+	if err := tx.Where("totp_secret <> ''").Find(&users).Error; err != nil {
+		return err
+	}
+	for _, u := range users {
+		value, err := convert(u.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		if value == u.TOTPSecret {
+			continue
+		}
+		if err := tx.Model(&user0010{}).Where("user_id = ?", u.UserID).
+			Update("totp_secret", value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package migrations
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migration 一次带版本号的表结构变更，Up/Down 在同一事务中执行
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行迁移的记录表
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 单个迁移的执行状态
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var registry = map[int64]Migration{}
+
+// register 注册迁移，版本号重复时直接 panic（属于编码错误）
+func register(m Migration) {
+	if _, ok := registry[m.Version]; ok {
+		panic(fmt.Sprintf("迁移版本号重复: %d", m.Version))
+	}
+	if m.Up == nil || m.Down == nil {
+		panic(fmt.Sprintf("迁移 %d_%s 必须同时实现 Up 和 Down", m.Version, m.Name))
+	}
+	registry[m.Version] = m
+}
+
+// All 按版本号升序返回所有已注册的迁移
+func All() []Migration {
+	list := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// ensureTable 确保 schema_migrations 表存在
+func ensureTable(db *gorm.DB) error {
+	return db.AutoMigrate(&SchemaMigration{})
+}
+
+// applied 查询已执行的迁移，以版本号为键
+func applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, fmt.Errorf("创建 schema_migrations 表失败: %v", err)
+	}
+
+	var records []SchemaMigration
+	if err := db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %v", err)
+	}
+
+	result := make(map[int64]SchemaMigration, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的迁移列表
+func Up(db *gorm.DB) ([]Migration, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range All() {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("迁移 %d_%s 执行失败: %v", m.Version, m.Name, err)
+		}
+
+		slog.Info("已执行迁移", "version", m.Version, "name", m.Name)
+		ran = append(ran, m)
+	}
+
+	return ran, nil
+}
+
+// Down 按版本号倒序回滚最近执行的 steps 个迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("回滚步数必须大于0")
+	}
+
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	all := All()
+	var rolled []Migration
+	for i := len(all) - 1; i >= 0 && len(rolled) < steps; i-- {
+		m := all[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return rolled, fmt.Errorf("迁移 %d_%s 回滚失败: %v", m.Version, m.Name, err)
+		}
+
+		slog.Info("已回滚迁移", "version", m.Version, "name", m.Name)
+		rolled = append(rolled, m)
+	}
+
+	return rolled, nil
+}
+
+// GetStatus 返回所有迁移的执行状态
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	for _, m := range All() {
+		s := Status{Version: m.Version, Name: m.Name}
+		if r, ok := done[m.Version]; ok {
+			s.Applied = true
+			appliedAt := r.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// CurrentVersion 返回已执行的最大迁移版本号，未执行任何迁移时为0
+func CurrentVersion(db *gorm.DB) (int64, error) {
+	done, err := applied(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range done {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// dropColumn 删除列。gorm 的 SQLite 驱动删除列时会重建整张表，表上的索引（包括唯一索引）
+// 会一并丢失，因此 SQLite 下直接使用 ALTER TABLE DROP COLUMN（要求该列上没有索引）
+func dropColumn(tx *gorm.DB, value interface{}, field string) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return tx.Migrator().DropColumn(value, field)
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(value); err != nil {
+		return err
+	}
+	column := field
+	if f := stmt.Schema.LookUpField(field); f != nil {
+		column = f.DBName
+	}
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Table}, clause.Column{Name: column}).Error
+}
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"blog/config"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	// 0010 加密 TOTP 密钥时需要 jwt.secret
+	config.Cfg = config.Default()
+	config.Cfg.JWT.Secret = "0123456789abcdef0123"
+	m.Run()
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// schema 当前的表结构：每张表的列名（已排序）和全部索引的定义。
+// 列的顺序和外键约束的顺序与建表方式有关，不参与比较
+type schema struct {
+	Columns map[string][]string
+	Indexes map[string]string
+}
+
+func readSchema(t *testing.T, db *gorm.DB) schema {
+	t.Helper()
+	var rows []struct {
+		Type string
+		Name string
+		SQL  string
+	}
+	if err := db.Raw("SELECT type, name, sql FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'").
+		Scan(&rows).Error; err != nil {
+		t.Fatalf("读取表结构失败: %v", err)
+	}
+
+	s := schema{Columns: map[string][]string{}, Indexes: map[string]string{}}
+	for _, r := range rows {
+		switch r.Type {
+		case "index":
+			s.Indexes[r.Name] = r.SQL
+		case "table":
+			types, err := db.Migrator().ColumnTypes(r.Name)
+			if err != nil {
+				t.Fatalf("读取 %s 的列失败: %v", r.Name, err)
+			}
+			var cols []string
+			for _, c := range types {
+				cols = append(cols, c.Name())
+			}
+			sort.Strings(cols)
+			s.Columns[r.Name] = cols
+		}
+	}
+	return s
+}
+
+func TestUpDownUp(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatalf("Up error = %v", err)
+	}
+	want := readSchema(t, db)
+
+	// 唯一索引在 SQLite 上重建表时容易丢失，单独确认
+	for _, name := range []string{"idx_users_username", "idx_users_email", "idx_oauth_platforms_platform", "idx_platform_user"} {
+		if !strings.Contains(want.Indexes[name], "UNIQUE") {
+			t.Errorf("unique index %s missing after Up: %q", name, want.Indexes[name])
+		}
+	}
+	if cols := want.Columns["users"]; contains(cols, "is_admin") || !contains(cols, "role") {
+		t.Errorf("users columns = %v, want role without is_admin", cols)
+	}
+
+	// 从最后一个迁移开始逐个加大回滚步数，每次回滚后重新执行，表结构都应与第一次完全相同
+	total := len(All())
+	for steps := 1; steps <= total; steps++ {
+		rolled, err := Down(db, steps)
+		if err != nil {
+			t.Fatalf("Down(%d) error = %v", steps, err)
+		}
+		if len(rolled) != steps {
+			t.Fatalf("Down(%d) rolled back %d migrations", steps, len(rolled))
+		}
+		if _, err := Up(db); err != nil {
+			t.Fatalf("Up after Down(%d) error = %v", steps, err)
+		}
+		got := readSchema(t, db)
+		if !reflect.DeepEqual(got.Indexes, want.Indexes) {
+			t.Errorf("indexes after Down(%d)+Up differ:\n got %v\nwant %v", steps, got.Indexes, want.Indexes)
+		}
+		if !reflect.DeepEqual(got.Columns, want.Columns) {
+			t.Errorf("columns after Down(%d)+Up differ:\n got %v\nwant %v", steps, got.Columns, want.Columns)
+		}
+	}
+
+	// 全部回滚后只剩下记录迁移版本的表
+	if _, err := Down(db, total); err != nil {
+		t.Fatalf("Down(all) error = %v", err)
+	}
+	got := readSchema(t, db)
+	if len(got.Columns) != 1 || got.Columns[SchemaMigration{}.TableName()] == nil {
+		t.Errorf("tables after Down(all) = %v, want only %s", got.Columns, SchemaMigration{}.TableName())
+	}
+	if v, err := CurrentVersion(db); err != nil || v != 0 {
+		t.Errorf("CurrentVersion after Down(all) = %d, %v, want 0", v, err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityBearer 安全方案名称，对应 Authorization: Bearer <token>
+const SecurityBearer = "bearerAuth"
+
+// Route 描述一个 Gin 路由的文档信息，Method/Path 与注册时保持一致（Gin 风格路径）
+type Route struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Description string
+	Auth        bool        // 是否经过 JWTAuthMiddleware
+	Query       []Parameter // 查询参数，路径参数会自动从 Path 中解析
+	Body        interface{} // JSON 请求体类型的零值
+	Form        []FormField // multipart/form-data 表单字段
+	Data        interface{} // 成功时响应信封中 data 字段的类型
+	Raw         *Response   // 不使用统一响应信封的接口（静态文件、重定向、指标等）
+}
+
+// FormField multipart 表单字段
+type FormField struct {
+	Name        string
+	Description string
+	File        bool
+	Required    bool
+}
+
+// Generator 根据已注册的路由和文档表生成 OpenAPI 文档
+type Generator struct {
+	Info     Info
+	Tags     []Tag
+	Envelope interface{} // 统一响应结构，其中名为 Data 的字段替换为各接口的数据类型
+	Routes   []Route
+
+	// Resolve 将实际注册的路径映射为文档表中的路径（例如去掉 /api/v1 前缀），
+	// 并返回该路由是否已弃用；为 nil 时按原路径查找
+	Resolve func(method, path string) (docPath string, deprecated bool)
+}
+
+// Build 生成文档。以 routes（engine.Routes()）为准：
+// 已注册但没有文档的路由也会输出基础信息，未注册的文档条目会被忽略
+func (g *Generator) Build(routes gin.RoutesInfo) *Document {
+	reg := newSchemaRegistry()
+	docs := make(map[string]*Route, len(g.Routes))
+	for i := range g.Routes {
+		r := &g.Routes[i]
+		docs[r.Method+" "+r.Path] = r
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    g.Info,
+		Tags:    g.Tags,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				SecurityBearer: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "登录接口返回的 token，放在 Authorization: Bearer <token> 中",
+				},
+			},
+		},
+	}
+
+	sorted := append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, ri := range sorted {
+		docPath, deprecated := ri.Path, false
+		if g.Resolve != nil {
+			docPath, deprecated = g.Resolve(ri.Method, ri.Path)
+		}
+
+		r, ok := docs[ri.Method+" "+docPath]
+		if !ok && ri.Method == http.MethodHead {
+			// Static 同时注册 GET 和 HEAD，HEAD 沿用 GET 的文档
+			r, ok = docs[http.MethodGet+" "+docPath]
+		}
+		if !ok {
+			r = &Route{Method: ri.Method, Path: ri.Path, Summary: ri.Handler}
+		}
+
+		path, params := convertPath(ri.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		op := g.operation(reg, ri, r, params)
+		op.Deprecated = deprecated
+		doc.Paths[path][strings.ToLower(ri.Method)] = op
+	}
+
+	doc.Components.Schemas = reg.schemas
+	return doc
+}
+
+func (g *Generator) operation(reg *schemaRegistry, ri gin.RouteInfo, r *Route, params []Parameter) *Operation {
+	op := &Operation{
+		Summary:     r.Summary,
+		Description: r.Description,
+		OperationID: operationID(ri.Method, ri.Path),
+		Parameters:  append(params, r.Query...),
+		Responses:   make(map[string]*Response),
+	}
+
+	tag := r.Tag
+	if tag == "" {
+		tag = firstSegment(ri.Path)
+	}
+	if tag != "" {
+		op.Tags = []string{tag}
+	}
+
+	switch {
+	case r.Body != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: reg.SchemaOf(r.Body)},
+			},
+		}
+	case len(r.Form) > 0:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"multipart/form-data": {Schema: formSchema(r.Form)},
+			},
+		}
+	}
+
+	if r.Raw != nil {
+		op.Responses["200"] = r.Raw
+	} else {
+		op.Responses["200"] = &Response{
+			Description: "成功",
+			Content:     jsonContent(g.envelope(reg, reg.SchemaOf(r.Data))),
+		}
+		op.Responses["default"] = &Response{
+			Description: "失败，code 为字符串错误码，例如 content.not_found",
+			Content:     jsonContent(g.envelope(reg, &Schema{Type: "object"})),
+		}
+	}
+
+	if r.Auth {
+		op.Security = []map[string][]string{{SecurityBearer: {}}}
+		op.Responses["401"] = &Response{
+			Description: "未登录或 token 无效",
+			Content:     jsonContent(g.envelope(reg, &Schema{Type: "object"})),
+		}
+	}
+	return op
+}
+
+// envelope 生成统一响应信封的 Schema，data 字段替换为指定类型
+func (g *Generator) envelope(reg *schemaRegistry, data *Schema) *Schema {
+	if g.Envelope == nil {
+		return data
+	}
+	t := reflect.TypeOf(g.Envelope)
+	s := reg.structSchema(t)
+
+	if f, ok := t.FieldByName("Data"); ok {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		if data == nil {
+			data = &Schema{Nullable: true, Description: "无数据时为 null"}
+		}
+		s.Properties[name] = data
+	}
+	return s
+}
+
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+func formSchema(fields []FormField) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields {
+		prop := &Schema{Type: "string", Description: f.Description}
+		if f.File {
+			prop.Format = "binary"
+		}
+		s.Properties[f.Name] = prop
+		if f.Required {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+// convertPath 将 Gin 路径转换为 OpenAPI 路径，并解析出路径参数：
+// /content/:id -> /content/{id}，/img/*filepath -> /img/{filepath}
+func convertPath(ginPath string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(ginPath, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 由方法和路径生成唯一 ID，例如 GET /content/:id -> get_content_id
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.TrimLeft(seg, ":*")
+		if seg == "" {
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteString(strings.NewReplacer("-", "_", ".", "_").Replace(seg))
+	}
+	return b.String()
+}
+
+func firstSegment(path string) string {
+	seg, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+		return ""
+	}
+	return seg
+}
@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+var swaggerTmpl = template.Must(template.New("swagger").Parse(swaggerHTML))
+
+// SpecHandler 返回 OpenAPI 文档。文档在第一次请求时生成，此时所有路由都已注册
+func SpecHandler(build func() *Document) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *Document
+	)
+	return func(c *gin.Context) {
+		once.Do(func() { doc = build() })
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// UIHandler 返回 Swagger UI 页面，specURL 为 OpenAPI 文档地址
+func UIHandler(title, specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		_ = swaggerTmpl.Execute(c.Writer, struct{ Title, SpecURL string }{title, specURL})
+	}
+}
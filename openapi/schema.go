@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRegistry 通过反射生成 Schema，具名结构体放入 components 并返回 $ref，
+// 这样可以处理 User <-> OAuthAccount 之类的循环引用
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// SchemaOf 生成 v 的 Schema，v 为 nil 时返回 nil
+func (r *schemaRegistry) SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return r.schemaForType(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaForType(t.Elem())}
+	case reflect.Interface:
+		return &Schema{Description: "任意类型"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.namedStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// namedStruct 具名结构体注册到 components/schemas
+func (r *schemaRegistry) namedStruct(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = r.uniqueName(t)
+		r.names[t] = name
+		// 先占位，防止递归时重复生成
+		r.schemas[name] = &Schema{}
+		*r.schemas[name] = *r.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// uniqueName 生成组件名，不同包的同名类型追加序号
+func (r *schemaRegistry) uniqueName(t reflect.Type) string {
+	base := t.Name()
+	if base != "" {
+		base = strings.ToUpper(base[:1]) + base[1:]
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, exists := r.schemas[name]; !exists {
+			return name
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.collectFields(t, s)
+	return s
+}
+
+// collectFields 按 encoding/json 的规则收集字段，包含嵌入结构体的字段
+func (r *schemaRegistry) collectFields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		// 匿名嵌入且未指定 json 名称：字段提升到外层
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				r.collectFields(ft, s)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := r.schemaForType(f.Type)
+		if f.Type.Kind() == reflect.Ptr && prop.Ref == "" {
+			prop.Nullable = true
+		}
+		applyBinding(prop, f.Tag.Get("binding"))
+		s.Properties[name] = prop
+
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// applyBinding 将 gin binding 标签中的常见规则映射到 Schema
+func applyBinding(s *Schema, binding string) {
+	if binding == "" || s.Ref != "" {
+		return
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		key, val, _ := strings.Cut(rule, "=")
+		switch key {
+		case "email":
+			s.Format = "email"
+		case "min", "max":
+			n, err := strconv.Atoi(val)
+			if err != nil || s.Type != "string" {
+				continue
+			}
+			if key == "min" {
+				s.MinLength = &n
+			} else {
+				s.MaxLength = &n
+			}
+		case "oneof":
+			s.Enum = strings.Fields(val)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package policy 用户名和密码的规则检查，规则来自 config.Cfg.Password / config.Cfg.Username。
+// 检查不会在第一条违反的规则处停止，而是返回全部违反的规则，便于客户端一次性提示
+package policy
+
+import (
+	"blog/config"
+	"blog/constants"
+	"blog/i18n"
+	"bufio"
+	_ "embed"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcryptMaxBytes bcrypt 只使用密码的前 72 字节，超出部分会被拒绝
+const bcryptMaxBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Violation 违反的一条规则
+type Violation struct {
+	Subject string // password 或 username
+	Rule    string // 规则名称，例如 min_length、common
+	Param   string // 规则参数，例如最少长度
+}
+
+// Message 规则的说明，译文 key 为 policy.<subject>.<rule>
+func (v Violation) Message(lang string) string {
+	key := "policy." + v.Subject + "." + v.Rule
+	if v.Param != "" {
+		return i18n.T(lang, key, v.Param)
+	}
+	return i18n.T(lang, key)
+}
+
+// Error 未通过规则检查，Violations 为全部违反的规则
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Subject+"."+v.Rule)
+	}
+	return "未通过规则检查: " + strings.Join(rules, ", ")
+}
+
+// FieldErrors 转换为请求字段 field 的校验错误，用于 request.validation_failed 响应
+func FieldErrors(lang, field string, violations []Violation) []constants.FieldError {
+	list := make([]constants.FieldError, 0, len(violations))
+	for _, v := range violations {
+		list = append(list, constants.FieldError{
+			Field:   field,
+			Rule:    v.Rule,
+			Param:   v.Param,
+			Message: v.Message(lang),
+		})
+	}
+	return list
+}
+
+// Password 检查新密码，username 为该密码所属的用户名（用于禁止密码包含用户名）
+func Password(password, username string) []Violation {
+	cfg := config.Cfg.Password
+	var list []Violation
+	add := func(rule string, param int) {
+		v := Violation{Subject: "password", Rule: rule}
+		if param > 0 {
+			v.Param = strconv.Itoa(param)
+		}
+		list = append(list, v)
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < cfg.MinLength {
+		add("min_length", cfg.MinLength)
+	}
+	if length > cfg.MaxLength || len(password) > bcryptMaxBytes {
+		add("max_length", cfg.MaxLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			classes++
+		}
+	}
+	if classes < cfg.MinClasses {
+		add("min_classes", cfg.MinClasses)
+	}
+	if cfg.RequireLower && !lower {
+		add("require_lower", 0)
+	}
+	if cfg.RequireUpper && !upper {
+		add("require_upper", 0)
+	}
+	if cfg.RequireDigit && !digit {
+		add("require_digit", 0)
+	}
+	if cfg.RequireSymbol && !symbol {
+		add("require_symbol", 0)
+	}
+
+	if isBlocked(password) {
+		add("common", 0)
+	}
+	// 过短的用户名（例如单个字母）几乎出现在所有密码中，不做检查
+	name := strings.ToLower(strings.TrimSpace(username))
+	if cfg.DisallowUsername && utf8.RuneCountInString(name) >= 3 &&
+		strings.Contains(strings.ToLower(password), name) {
+		add("contains_username", 0)
+	}
+	return list
+}
+
+// Username 检查注册时的用户名
+func Username(username string) []Violation {
+	cfg := config.Cfg.Username
+	var list []Violation
+	add := func(rule string, param int) {
+		v := Violation{Subject: "username", Rule: rule}
+		if param > 0 {
+			v.Param = strconv.Itoa(param)
+		}
+		list = append(list, v)
+	}
+
+	length := utf8.RuneCountInString(username)
+	if length < cfg.MinLength {
+		add("min_length", cfg.MinLength)
+	}
+	if length > cfg.MaxLength {
+		add("max_length", cfg.MaxLength)
+	}
+	if re := usernamePattern(); re != nil && !re.MatchString(username) {
+		add("pattern", 0)
+	}
+	for _, reserved := range cfg.Reserved {
+		if strings.EqualFold(username, reserved) {
+			add("reserved", 0)
+			break
+		}
+	}
+	return list
+}
+
+var (
+	blocklistOnce sync.Once
+	blocklist     map[string]struct{}
+
+	patternOnce sync.Once
+	pattern     *regexp.Regexp
+)
+
+// isBlocked 密码是否在弱密码列表中（内置列表加上 blocklist_file），不区分大小写
+func isBlocked(password string) bool {
+	cfg := config.Cfg.Password
+	if !cfg.BlockCommon && cfg.BlocklistFile == "" {
+		return false
+	}
+	blocklistOnce.Do(func() {
+		blocklist = make(map[string]struct{})
+		if cfg.BlockCommon {
+			readList(strings.NewReader(commonPasswords))
+		}
+		if cfg.BlocklistFile == "" {
+			return
+		}
+		f, err := os.Open(cfg.BlocklistFile)
+		if err != nil {
+			slog.Error("读取弱密码列表失败", "path", cfg.BlocklistFile, "error", err)
+			return
+		}
+		defer f.Close()
+		readList(f)
+	})
+	_, ok := blocklist[strings.ToLower(password)]
+	return ok
+}
+
+// readList 读取每行一个的密码列表，忽略空行和 # 开头的注释
+func readList(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("读取弱密码列表失败", "error", err)
+	}
+}
+
+// usernamePattern 编译 username_policy.pattern，配置校验时已确认可以编译
+func usernamePattern() *regexp.Regexp {
+	patternOnce.Do(func() {
+		if p := config.Cfg.Username.Pattern; p != "" {
+			pattern = regexp.MustCompile(p)
+		}
+	})
+	return pattern
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"blog/config"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	config.Cfg = config.Default()
+	m.Run()
+}
+
+// rules 违反的规则名称，便于和期望值比较
+func rules(list []Violation) []string {
+	var names []string
+	for _, v := range list {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		username string
+		want     []string
+	}{
+		{"valid", "Secur3pass", "alice", nil},
+		{"too short", "Ab1", "alice", []string{"min_length"}},
+		{"single class", "abcdefghij", "alice", []string{"min_classes"}},
+		{"short and single class", "abc", "alice", []string{"min_length", "min_classes"}},
+		{"common password", "Password1", "alice", []string{"common"}},
+		{"contains username", "xxAlice99", "alice", []string{"contains_username"}},
+		{"short username is ignored", "xxAl99xx", "al", nil},
+		{"over bcrypt limit", strings.Repeat("a1", 37), "alice", []string{"max_length"}},
+		{"multibyte over bcrypt limit", strings.Repeat("ä1", 25), "alice", []string{"max_length"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(Password(tt.password, tt.username)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Password(%q, %q) = %v, want %v", tt.password, tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordRequiredClasses(t *testing.T) {
+	saved := config.Cfg.Password
+	t.Cleanup(func() { config.Cfg.Password = saved })
+	config.Cfg.Password.RequireUpper = true
+	config.Cfg.Password.RequireSymbol = true
+
+	got := rules(Password("secur3pass", "alice"))
+	want := []string{"require_upper", "require_symbol"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Password = %v, want %v", got, want)
+	}
+	if got := rules(Password("Secur3pass!", "alice")); got != nil {
+		t.Errorf("Password = %v, want no violations", got)
+	}
+}
+
+func TestUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     []string
+	}{
+		{"valid", "alice_01", nil},
+		{"too short", "ab", []string{"min_length"}},
+		{"too long", strings.Repeat("a", 31), []string{"max_length"}},
+		{"bad characters", "alice-01", []string{"pattern"}},
+		{"reserved", "Admin", []string{"reserved"}},
+		{"short with bad characters", "a!", []string{"min_length", "pattern"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(Username(tt.username)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Username(%q) = %v, want %v", tt.username, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"blog/Model"
+	"blog/constants"
+	"blog/database"
+	"blog/logger"
+	"blog/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// subjectKey 当前用户的角色信息在 gin.Context 中的键，同一请求内只查询一次数据库
+const subjectKey = "rbac_subject"
+
+// mfaPendingRole 必须启用两步验证但尚未启用的用户（mfa.require_for_admins 下的管理员）
+// 在启用前按该角色计算权限：可以照常写作，不能执行管理操作
+const mfaPendingRole = Model.RoleAuthor
+
+// subject 发起请求的用户
+type subject struct {
+	userID     uint
+	role       string
+	mfaPending bool
+}
+
+// loadSubject 读取当前用户的角色，需在 JWT 中间件之后使用
+func loadSubject(c *gin.Context) (*subject, error) {
+	if v, ok := c.Get(subjectKey); ok {
+		return v.(*subject), nil
+	}
+
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var user Model.User
+	if err := database.DB.Select("user_id", "role", "totp_secret", "mfa_enabled_at").
+		First(&user, userID.(int64)).Error; err != nil {
+		return nil, err
+	}
+
+	s := &subject{
+		userID:     user.UserID,
+		role:       user.Role,
+		mfaPending: service.MFAEnrollmentRequired(&user),
+	}
+	c.Set(subjectKey, s)
+	return s, nil
+}
+
+// check 判断当前用户能否执行操作，allowed 在给定角色下判断是否允许；
+// 返回 nil 表示允许，否则返回应当响应的错误码（denied 或要求先启用两步验证）
+func check(c *gin.Context, allowed func(role string, userID uint) bool, denied constants.StatusCode) constants.StatusCode {
+	s, err := loadSubject(c)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return constants.Unauthorized
+	}
+	if err != nil {
+		logger.FromGin(c).Error("读取用户角色失败", "error", err)
+		return constants.SystemError
+	}
+
+	role := s.role
+	if s.mfaPending {
+		role = mfaPendingRole
+	}
+	if allowed(role, s.userID) {
+		return nil
+	}
+	if s.mfaPending && allowed(s.role, s.userID) {
+		return constants.AuthMFAEnrollmentRequired
+	}
+	return denied
+}
+
+// RequirePermission 要求当前用户拥有 perm 权限（任意范围），需在 JWT 中间件之后使用。
+// 对于有归属的资源，handler 还需要用 Authorize 检查能否操作具体的资源
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := check(c, func(role string, _ uint) bool {
+			return ScopeOf(role, perm) != ScopeNone
+		}, constants.Forbidden)
+		if status != nil {
+			constants.SendResponse(c, status, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authorize 检查当前用户能否对 ownerID 拥有的资源执行 perm，
+// 不允许时写入 denied（或要求先启用两步验证）并返回 false
+func Authorize(c *gin.Context, perm Permission, ownerID uint, denied constants.StatusCode) bool {
+	status := check(c, func(role string, userID uint) bool {
+		return Allows(role, perm, userID, ownerID)
+	}, denied)
+	if status != nil {
+		constants.SendResponse(c, status, nil)
+		return false
+	}
+	return true
+}
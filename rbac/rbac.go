@@ -0,0 +1,156 @@
+// Package rbac 基于角色的权限控制：每个用户属于一个角色（Model.Role*），
+// 角色拥有一组命名权限。针对内容、评论等有归属的资源，权限分为
+// "只能操作自己的"（ScopeOwn）和"可以操作所有人的"（ScopeAll）两种范围
+package rbac
+
+import (
+	"blog/Model"
+	"sort"
+)
+
+// Permission 权限名称，格式为 <资源>.<操作>
+type Permission string
+
+const (
+	ContentCreate Permission = "content.create" // 发表内容
+	ContentUpdate Permission = "content.update" // 修改内容及其标签、文件
+	ContentDelete Permission = "content.delete" // 删除内容
+	CommentCreate Permission = "comment.create" // 发表评论
+	CommentUpdate Permission = "comment.update" // 修改评论
+	CommentDelete Permission = "comment.delete" // 删除评论
+	TagCreate     Permission = "tag.create"     // 创建标签
+	TagUpdate     Permission = "tag.update"     // 修改标签
+	TagDelete     Permission = "tag.delete"     // 删除标签
+	FileUpload    Permission = "file.upload"    // 上传图片和文件
+	UserList      Permission = "user.list"      // 查看用户列表
+	UserUpdate    Permission = "user.update"    // 修改其他用户的资料
+	UserDelete    Permission = "user.delete"    // 删除其他用户
+	UserBan       Permission = "user.ban"       // 封禁、解封用户，解除登录锁定
+	UserRole      Permission = "user.role"      // 查看角色定义、为用户分配角色
+	OAuthManage   Permission = "oauth.manage"   // 配置第三方登录平台
+	SystemDebug   Permission = "system.debug"   // 查看诊断信息
+)
+
+// Scope 权限的范围
+type Scope int
+
+const (
+	ScopeNone Scope = iota
+	ScopeOwn        // 只能操作自己创建的资源
+	ScopeAll        // 可以操作所有资源
+)
+
+// grants 角色拥有的权限及范围
+type grants map[Permission]Scope
+
+// with 在已有权限的基础上追加（或放大范围），用于逐级构建角色
+func (g grants) with(extra grants) grants {
+	merged := make(grants, len(g)+len(extra))
+	for p, s := range g {
+		merged[p] = s
+	}
+	for p, s := range extra {
+		if s > merged[p] {
+			merged[p] = s
+		}
+	}
+	return merged
+}
+
+var (
+	commenterGrants = grants{
+		CommentCreate: ScopeAll,
+		CommentUpdate: ScopeOwn,
+		CommentDelete: ScopeOwn,
+	}
+	authorGrants = commenterGrants.with(grants{
+		ContentCreate: ScopeAll,
+		ContentUpdate: ScopeOwn,
+		ContentDelete: ScopeOwn,
+		FileUpload:    ScopeAll,
+	})
+	editorGrants = authorGrants.with(grants{
+		ContentUpdate: ScopeAll,
+		ContentDelete: ScopeAll,
+		CommentDelete: ScopeAll,
+		TagCreate:     ScopeAll,
+		TagUpdate:     ScopeAll,
+		TagDelete:     ScopeAll,
+	})
+	adminGrants = editorGrants.with(grants{
+		CommentUpdate: ScopeAll,
+		UserList:      ScopeAll,
+		UserUpdate:    ScopeAll,
+		UserDelete:    ScopeAll,
+		UserBan:       ScopeAll,
+		UserRole:      ScopeAll,
+		OAuthManage:   ScopeAll,
+		SystemDebug:   ScopeAll,
+	})
+)
+
+// roles 角色定义，顺序即展示顺序（权限从高到低）
+var roles = []struct {
+	name   string
+	grants grants
+}{
+	{Model.RoleAdmin, adminGrants},
+	{Model.RoleEditor, editorGrants},
+	{Model.RoleAuthor, authorGrants},
+	{Model.RoleCommenter, commenterGrants},
+}
+
+// roleGrants 角色拥有的权限，未知角色没有任何权限
+func roleGrants(role string) grants {
+	for _, r := range roles {
+		if r.name == role {
+			return r.grants
+		}
+	}
+	return nil
+}
+
+// ValidRole 是否为已定义的角色
+func ValidRole(role string) bool {
+	return roleGrants(role) != nil
+}
+
+// RoleNames 全部角色名称，权限从高到低
+func RoleNames() []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// ScopeOf 角色对某项权限的范围
+func ScopeOf(role string, perm Permission) Scope {
+	return roleGrants(role)[perm]
+}
+
+// Allows 角色为 userID 的用户能否对 ownerID 拥有的资源执行 perm
+func Allows(role string, perm Permission, userID, ownerID uint) bool {
+	switch ScopeOf(role, perm) {
+	case ScopeAll:
+		return true
+	case ScopeOwn:
+		return userID != 0 && ownerID == userID
+	}
+	return false
+}
+
+// Permissions 角色拥有的权限列表，只能操作自己资源的权限带 ":own" 后缀，例如 content.update:own
+func Permissions(role string) []string {
+	g := roleGrants(role)
+	list := make([]string, 0, len(g))
+	for p, s := range g {
+		name := string(p)
+		if s == ScopeOwn {
+			name += ":own"
+		}
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"blog/Model"
+	"reflect"
+	"testing"
+)
+
+func TestAllows(t *testing.T) {
+	const (
+		self  uint = 1
+		other uint = 2
+	)
+	tests := []struct {
+		role    string
+		perm    Permission
+		userID  uint
+		ownerID uint
+		want    bool
+	}{
+		// 只能操作自己的资源
+		{Model.RoleAuthor, ContentUpdate, self, self, true},
+		{Model.RoleAuthor, ContentUpdate, self, other, false},
+		{Model.RoleAuthor, ContentDelete, self, other, false},
+		{Model.RoleAuthor, ContentUpdate, 0, 0, false},
+		{Model.RoleCommenter, CommentUpdate, self, self, true},
+		{Model.RoleCommenter, CommentDelete, self, other, false},
+		// 可以操作所有人的资源
+		{Model.RoleEditor, ContentUpdate, self, other, true},
+		{Model.RoleEditor, CommentDelete, self, other, true},
+		{Model.RoleAdmin, CommentUpdate, self, other, true},
+		{Model.RoleAdmin, SystemDebug, self, 0, true},
+		// 编辑只能删除别人的评论，不能修改
+		{Model.RoleEditor, CommentUpdate, self, other, false},
+		// 没有该权限
+		{Model.RoleCommenter, ContentCreate, self, 0, false},
+		{Model.RoleAuthor, TagCreate, self, 0, false},
+		{Model.RoleEditor, UserBan, self, other, false},
+		{Model.RoleEditor, SystemDebug, self, 0, false},
+		// 未知角色没有任何权限
+		{"", CommentCreate, self, 0, false},
+		{"owner", ContentUpdate, self, self, false},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.role, tt.perm, tt.userID, tt.ownerID); got != tt.want {
+			t.Errorf("Allows(%q, %s, %d, %d) = %v, want %v", tt.role, tt.perm, tt.userID, tt.ownerID, got, tt.want)
+		}
+	}
+}
+
+func TestRoleHierarchy(t *testing.T) {
+	// 高一级的角色拥有低一级角色的全部权限，范围不会变小
+	names := RoleNames()
+	for i := 0; i+1 < len(names); i++ {
+		higher, lower := names[i], names[i+1]
+		for perm, scope := range roleGrants(lower) {
+			if got := ScopeOf(higher, perm); got < scope {
+				t.Errorf("ScopeOf(%q, %s) = %d, lower role %q has %d", higher, perm, got, lower, scope)
+			}
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	got := Permissions(Model.RoleCommenter)
+	want := []string{"comment.create", "comment.delete:own", "comment.update:own"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Permissions(commenter) = %v, want %v", got, want)
+	}
+	if got := Permissions("unknown"); len(got) != 0 {
+		t.Errorf("Permissions(unknown) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,82 @@
+// Package secretbox 使用服务端密钥加密需要存入数据库、之后还要还原出明文的敏感数据（例如 TOTP 密钥）。
+// 算法为 AES-256-GCM，密钥由 mfa.secret_key（为空时为 jwt.secret）派生
+package secretbox
+
+import (
+	"blog/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix 密文的版本前缀，以后更换算法时据此区分
+const prefix = "v1:"
+
+// ErrInvalid 密文格式错误、被篡改或密钥不匹配
+var ErrInvalid = errors.New("密文无效或密钥不匹配")
+
+// key 派生 AES-256 密钥，加上用途前缀避免与 JWT 签名直接共用同一个密钥
+func key() []byte {
+	secret := config.Cfg.MFA.SecretKey
+	if secret == "" {
+		secret = config.Cfg.JWT.Secret
+	}
+	sum := sha256.Sum256([]byte("blog secretbox v1\x00" + secret))
+	return sum[:]
+}
+
+func newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsSealed 是否为 Seal 生成的密文
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Seal 加密明文，返回带版本前缀的 base64 字符串
+func Seal(plain string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return prefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 生成的密文
+func Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return "", ErrInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return "", ErrInvalid
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", ErrInvalid
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	return string(plain), nil
+}
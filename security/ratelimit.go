@@ -0,0 +1,122 @@
+package security
+
+import (
+	"blog/config"
+	"blog/constants"
+	"blog/database"
+	"blog/logger"
+	"blog/metrics"
+	"blog/service"
+	"blog/utils"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware 基于 KV 存储的滑动窗口限流，多实例部署时通过 Redis 共享计数
+//
+// route 将 c.FullPath() 转换为不含版本前缀的路由（/api/v1/user/login -> /user/login），
+// 这样新旧路径使用同一条策略并共享配额。
+// 响应带 RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset / RateLimit-Policy 头，
+// 超出限制时返回 429 和 Retry-After。KV 存储不可用时放行请求。
+func RateLimitMiddleware(route func(fullPath string) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Cfg.RateLimit
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		name, policy := cfg.PolicyFor(c.Request.Method, route(c.FullPath()))
+		if policy.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		result, err := slidingWindow(name, rateLimitSubject(c), policy, time.Now())
+		if err != nil {
+			logger.FromGin(c).Warn("限流计数失败，已放行请求", "policy", name, "error", err)
+			c.Next()
+			return
+		}
+
+		reset := strconv.Itoa(int(math.Ceil(result.reset.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("RateLimit-Reset", reset)
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+
+		if !result.allowed {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			c.Header("Retry-After", reset)
+			c.Abort()
+			constants.SendResponse(c, constants.RateLimited, nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitSubject 计数对象：携带有效 token 时按用户，否则按客户端 IP
+// 这里只校验签名和有效期，不查询 token 是否已被登出，避免每个请求多一次 KV 访问
+func rateLimitSubject(c *gin.Context) string {
+	if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "bearer") {
+		if claims, err := utils.ParseToken(token); err == nil {
+			return fmt.Sprintf("user:%d", claims.UserID)
+		}
+	}
+	return "ip:" + service.Utils.GetClientIP(c)
+}
+
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	reset     time.Duration // 距离当前窗口结束的时间
+}
+
+// slidingWindow 滑动窗口计数：按固定窗口计数，再用上一窗口的计数按剩余比例加权估算
+// 最近一个 Window 内的请求数，既避免了固定窗口在边界处放行两倍请求，又只需两个计数键
+func slidingWindow(name, subject string, p config.RateLimitPolicy, now time.Time) (rateLimitResult, error) {
+	window := int64(p.Window)
+	index := now.UnixNano() / window
+	elapsed := now.UnixNano() - index*window
+
+	prefix := fmt.Sprintf("ratelimit:%s:%s:", strings.ReplaceAll(name, " ", ":"), subject)
+	current := prefix + strconv.FormatInt(index, 10)
+
+	// 当前窗口的计数在下一个窗口中仍要用于加权，保留两个窗口
+	count, err := database.IncrementWithTTL(current, 2*p.Window)
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+
+	var previous int64
+	if v, err := database.GetString(prefix + strconv.FormatInt(index-1, 10)); err == nil {
+		previous, _ = strconv.ParseInt(v, 10, 64)
+	} else if !errors.Is(err, database.ErrNil) {
+		return rateLimitResult{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(window)
+	estimate := float64(previous)*weight + float64(count)
+	reset := time.Duration(window - elapsed)
+
+	if estimate > float64(p.Limit) {
+		// 被拒绝的请求不占用配额
+		if _, err := database.Decrement(current); err != nil {
+			return rateLimitResult{}, err
+		}
+		return rateLimitResult{allowed: false, remaining: 0, reset: reset}, nil
+	}
+
+	return rateLimitResult{
+		allowed:   true,
+		remaining: p.Limit - int(math.Ceil(estimate)),
+		reset:     reset,
+	}, nil
+}
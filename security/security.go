@@ -1,6 +1,8 @@
 package security
 
 import (
+	"blog/config"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,14 +14,10 @@ func SetupCORSMiddleware() gin.HandlerFunc {
 		origin := c.Request.Header.Get("Origin")
 
 		// 安全的做法：只允许特定域名，防止 CSRF 攻击
-		// 生产环境请将 allowedOrigins 改为你实际的前端域名
-		allowedOrigins := map[string]bool{
-			"http://localhost:23357": true,
-			"http://yixixiawa.xyz":   true,
-		}
+		// 白名单在配置文件 cors.allowed_origins 中维护
 
 		// 检查 Origin 是否在白名单中
-		if origin != "" && allowedOrigins[origin] {
+		if origin != "" && config.Cfg.IsAllowedOrigin(origin) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
@@ -27,10 +25,10 @@ func SetupCORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 
 		// 允许的请求头
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID")
 
 		// 允许客户端访问的响应头
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, X-Request-ID, Content-Language, Deprecation, Sunset, Link, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 		// 浏览器可以缓存预检请求的结果（单位：秒，这里设为 24 小时）
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
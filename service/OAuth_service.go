@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"blog/Model"
@@ -14,6 +15,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// OAuth 服务返回的业务错误，控制层据此映射错误码
+var (
+	ErrOAuthAccountBound    = errors.New("该第三方账号已被其他用户绑定")
+	ErrOAuthLastLoginMethod = errors.New("无法解绑最后一个登录方式，请先设置密码")
+	ErrOAuthBindingNotFound = errors.New("未找到绑定关系")
+	ErrOAuthEmailConflict   = errors.New("邮箱已被其他账号使用且未经双方验证，不能自动关联")
+)
+
 // OAuthService OAuth服务
 type OAuthService struct {
 	db *gorm.DB
@@ -55,6 +64,45 @@ func (s *OAuthService) GetPlatformByName(platform string) (*Model.OAuthPlatform,
 	return &platformModel, nil
 }
 
+// SavePlatform 添加或更新平台配置，未填写的地址和scope使用平台默认值
+// 返回值 created 表示是否为新建
+func (s *OAuthService) SavePlatform(platform *Model.OAuthPlatform) (created bool, err error) {
+	if platform.AuthURL == "" {
+		platform.AuthURL = utils.GetPlatformAuthURL(platform.Platform)
+	}
+	if platform.TokenURL == "" {
+		platform.TokenURL = utils.GetPlatformTokenURL(platform.Platform)
+	}
+	if platform.UserInfoURL == "" {
+		platform.UserInfoURL = utils.GetPlatformUserInfoURL(platform.Platform)
+	}
+	if platform.Scopes == "" {
+		platform.Scopes = utils.GetPlatformScopes(platform.Platform)
+	}
+	if platform.AuthURL == "" || platform.TokenURL == "" {
+		return false, fmt.Errorf("平台 %s 没有默认的授权地址，请手动指定", platform.Platform)
+	}
+
+	var existing Model.OAuthPlatform
+	result := s.db.Where("platform = ?", platform.Platform).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		// 更新已有配置
+		err := s.db.Model(&existing).Updates(map[string]interface{}{
+			"client_id":     platform.ClientID,
+			"client_secret": platform.ClientSecret,
+			"redirect_url":  platform.RedirectURL,
+		}).Error
+		*platform = existing
+		return false, err
+	}
+
+	platform.IsEnabled = true
+	return true, s.db.Create(platform).Error
+}
+
 // CreateOAuthConfig 创建OAuth2.0配置
 func (s *OAuthService) CreateOAuthConfig(platform *Model.OAuthPlatform) *oauth2.Config {
 	scopes := utils.ParseScopes(platform.Scopes)
@@ -107,7 +155,7 @@ func (s *OAuthService) BindOAuthAccount(userID uint, platformID uint, platformUs
 		Count(&count)
 
 	if count > 0 {
-		return errors.New("该第三方账号已被其他用户绑定")
+		return ErrOAuthAccountBound
 	}
 
 	// 将userInfo转为JSON
@@ -183,13 +231,13 @@ func (s *OAuthService) UnbindOAuthAccount(userID uint, platformID uint) error {
 
 	// 如果这是最后一个登录方式且用户没有密码，不允许解绑
 	if count <= 1 && user.Password == "" {
-		return errors.New("无法解绑最后一个登录方式，请先设置密码")
+		return ErrOAuthLastLoginMethod
 	}
 
 	// 删除绑定
 	result := s.db.Where("user_id = ? AND platform_id = ?", userID, platformID).Delete(&Model.OAuthAccount{})
 	if result.RowsAffected == 0 {
-		return errors.New("未找到绑定关系")
+		return ErrOAuthBindingNotFound
 	}
 
 	return nil
@@ -210,11 +258,20 @@ func (s *OAuthService) CreateOrUpdateUser(platform *Model.OAuthPlatform, userInf
 		avatar, _ = userInfo["picture"].(string)
 	}
 
-	// 优先通过email查找用户
+	emailVerified, _ := userInfo["email_verified"].(bool)
+
+	// 邮箱已被本站用户使用：只有双方都验证过该邮箱时才自动关联，
+	// 否则任何人都可以在第三方平台填写他人的邮箱来登录对方的账号
 	if email != "" {
-		err := s.db.Where("email = ?", email).First(&user).Error
+		err := s.db.Where("LOWER(email) = ?", strings.ToLower(email)).First(&user).Error
 		if err == nil {
-			return &user, nil
+			if emailVerified && user.EmailVerified() {
+				return &user, nil
+			}
+			return nil, ErrOAuthEmailConflict
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
 		}
 	}
 
@@ -243,6 +300,10 @@ func (s *OAuthService) CreateOrUpdateUser(platform *Model.OAuthPlatform, userInf
 		Avatar:   avatar,
 		Password: "", // 第三方登录用户初始无密码
 	}
+	if email != "" && emailVerified {
+		now := time.Now()
+		newUser.EmailVerifiedAt = &now
+	}
 
 	err := s.db.Create(&newUser).Error
 	if err != nil {
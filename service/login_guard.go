@@ -0,0 +1,237 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/database"
+	"blog/mail"
+	"blog/session"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 登录防暴力破解相关的业务错误，控制层据此映射错误码
+var (
+	ErrLoginThrottled     = errors.New("登录失败次数过多，请稍后再试")
+	ErrAccountLocked      = errors.New("账号因多次登录失败已被临时锁定")
+	ErrUnlockTokenInvalid = errors.New("解锁链接无效或已过期")
+)
+
+// 登录失败计数的KV键：
+//
+//	login_fail:user:<用户名>  同一用户名的失败次数，达到 max_failures 时锁定
+//	login_fail:ip:<IP>        同一 IP 的失败次数，达到 max_ip_failures 时拒绝该 IP
+//	login_delay:<用户名>      存在时需要等待，TTL 即剩余等待时间
+//	account_unlock:<哈希>     邮件中解锁令牌的哈希，值为用户ID
+func loginUserKey(username string) string {
+	return "login_fail:user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginIPKey(ip string) string {
+	return "login_fail:ip:" + ip
+}
+
+func loginDelayKey(username string) string {
+	return "login_delay:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func unlockKey(token string) string {
+	return "account_unlock:" + session.HashToken(token)
+}
+
+// CheckLoginAllowed 校验密码之前调用：该 IP 或用户名失败过多、或仍在等待时间内时返回
+// ErrLoginThrottled / ErrAccountLocked 以及需要等待的时间。
+// user 为用户名对应的用户，不存在时为 nil；不存在的用户名同样会被计数和锁定，避免通过响应探测用户名
+func CheckLoginAllowed(username, ip string, user *Model.User) (time.Duration, error) {
+	cfg := config.Cfg.Login
+	if !cfg.Protection {
+		return 0, nil
+	}
+
+	if user != nil && user.IsLocked() {
+		return time.Until(*user.LockedUntil), ErrAccountLocked
+	}
+	if n, err := failureCount(loginUserKey(username)); err != nil {
+		return 0, err
+	} else if n >= cfg.MaxFailures {
+		return remaining(loginUserKey(username)), ErrAccountLocked
+	}
+	if n, err := failureCount(loginIPKey(ip)); err != nil {
+		return 0, err
+	} else if n >= cfg.MaxIPFailures {
+		return remaining(loginIPKey(ip)), ErrLoginThrottled
+	}
+	if wait := remaining(loginDelayKey(username)); wait > 0 {
+		return wait, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+// RecordLoginFailure 记录一次密码错误：累计失败次数并设置下次尝试前的等待时间；
+// 同一用户名失败达到上限时锁定账号并（在后台）向账号邮箱发送解锁链接，返回 ErrAccountLocked。
+// lang 为邮件语言，用户设置了语言偏好时以偏好为准
+func RecordLoginFailure(username, ip string, user *Model.User, lang string) (time.Duration, error) {
+	cfg := config.Cfg.Login
+	if !cfg.Protection {
+		return 0, nil
+	}
+
+	if _, err := incrementFailures(loginIPKey(ip), cfg.Window); err != nil {
+		return 0, err
+	}
+	n, err := incrementFailures(loginUserKey(username), cfg.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	if int(n) >= cfg.MaxFailures {
+		// 锁定期间计数一直保留，不存在的用户名也按锁定处理
+		if err := database.SetExpire(loginUserKey(username), cfg.LockDuration); err != nil {
+			return 0, err
+		}
+		if user != nil {
+			if err := lockAccount(user, ip, lang); err != nil {
+				return 0, err
+			}
+		}
+		return cfg.LockDuration, ErrAccountLocked
+	}
+
+	if int(n) < cfg.DelayAfter {
+		return 0, nil
+	}
+	delay := cfg.BaseDelay << min(int(n)-cfg.DelayAfter, 30)
+	if delay <= 0 || delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	if err := database.SetString(loginDelayKey(username), strconv.FormatInt(n, 10), delay); err != nil {
+		return 0, err
+	}
+	return delay, nil
+}
+
+// ResetLoginFailures 密码验证通过后清除该用户名的失败次数和等待时间（IP 的计数保留）
+func ResetLoginFailures(username string) error {
+	if !config.Cfg.Login.Protection {
+		return nil
+	}
+	return database.DeleteKeys(loginUserKey(username), loginDelayKey(username))
+}
+
+// lockAccount 锁定账号并发送带解锁链接的邮件
+func lockAccount(user *Model.User, ip, lang string) error {
+	cfg := config.Cfg.Login
+	until := time.Now().Add(cfg.LockDuration)
+	if err := database.DB.Model(user).Update("locked_until", until).Error; err != nil {
+		return err
+	}
+	user.LockedUntil = &until
+	slog.Warn("账号因多次登录失败被锁定", "user_id", user.UserID, "ip", ip, "until", until)
+
+	if user.Email == "" {
+		return nil
+	}
+	token, err := session.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := database.SetString(unlockKey(token), strconv.FormatUint(uint64(user.UserID), 10), cfg.LockDuration); err != nil {
+		return err
+	}
+	if user.Language != "" {
+		lang = user.Language
+	}
+	mail.SendAsync(mail.Request{
+		To:       user.Email,
+		Template: mail.TemplateAccountLocked,
+		Lang:     lang,
+		Data: map[string]any{
+			"Username":    user.Username,
+			"IP":          ip,
+			"LockMinutes": int(cfg.LockDuration.Minutes()),
+			"Token":       token,
+		},
+		ExpiresAt: until,
+	})
+	return nil
+}
+
+// UnlockAccount 解除账号的临时锁定并清除该用户名的失败次数
+func UnlockAccount(user *Model.User) error {
+	if err := database.DB.Model(user).Update("locked_until", nil).Error; err != nil {
+		return err
+	}
+	user.LockedUntil = nil
+	return database.DeleteKeys(loginUserKey(user.Username), loginDelayKey(user.Username))
+}
+
+// UnlockAccountWithToken 使用邮件中的解锁令牌解除锁定，令牌只能使用一次
+func UnlockAccountWithToken(token string) (*Model.User, error) {
+	key := unlockKey(token)
+	value, err := database.GetString(key)
+	if errors.Is(err, database.ErrNil) {
+		return nil, ErrUnlockTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Delete(key); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("解锁令牌数据损坏: %q", value)
+	}
+	var user Model.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnlockTokenInvalid
+		}
+		return nil, err
+	}
+	if err := UnlockAccount(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LockedAccounts 当前处于锁定中的用户，按解锁时间升序
+func LockedAccounts() ([]Model.User, error) {
+	var users []Model.User
+	err := database.DB.Where("locked_until > ?", time.Now()).Order("locked_until ASC").Find(&users).Error
+	return users, err
+}
+
+// failureCount 读取失败次数，不存在时为 0
+func failureCount(key string) (int, error) {
+	value, err := database.GetString(key)
+	if errors.Is(err, database.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, _ := strconv.Atoi(value)
+	return n, nil
+}
+
+// incrementFailures 失败次数加一，第一次失败时开始计算统计窗口
+func incrementFailures(key string, window time.Duration) (int64, error) {
+	return database.IncrementWithTTL(key, window)
+}
+
+// remaining 键的剩余有效期，不存在或没有过期时间时为 0
+func remaining(key string) time.Duration {
+	ttl, err := database.GetTTL(key)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/database"
+	"blog/secretbox"
+	"blog/session"
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+// 两步验证相关的业务错误，控制层据此映射错误码
+var (
+	ErrMFAAlreadyEnabled   = errors.New("已启用两步验证")
+	ErrMFANotEnabled       = errors.New("未启用两步验证")
+	ErrMFASetupExpired     = errors.New("两步验证绑定已过期，请重新开始")
+	ErrMFACodeInvalid      = errors.New("两步验证码错误")
+	ErrMFAChallengeInvalid = errors.New("两步验证已过期，请重新登录")
+	ErrMFATooManyAttempts  = errors.New("两步验证码错误次数过多，请稍后再试")
+)
+
+const (
+	// MFASetupTTL 开始绑定后需要在此时间内提交验证码完成启用
+	MFASetupTTL = 10 * time.Minute
+	// MFAChallengeTTL 密码验证通过后需要在此时间内提交两步验证码
+	MFAChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts MFAAttemptWindow 内允许输错两步验证码的次数，按用户统计，
+	// 登录第二步和已登录后的敏感操作共用，重新登录拿到新的临时令牌也不会重置
+	maxMFAAttempts = 5
+	// MFAAttemptWindow 两步验证码错误次数的统计窗口
+	MFAAttemptWindow = 15 * time.Minute
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// totpSkew 允许前后各一个时间窗口（30 秒）的时钟误差
+	totpSkew = 1
+)
+
+// KV 键：
+//
+//	mfa_setup:<userID>             绑定中的 TOTP 密钥
+//	mfa_challenge:<hash>           登录第二步的临时令牌 -> mfaChallenge（JSON）
+//	mfa_totp_used:<userID>:<code>  已使用过的验证码，防止同一个验证码被重放
+//	mfa_attempts:<userID>          两步验证码的错误次数（登录和已登录后的操作共用）
+func mfaSetupKey(userID uint) string     { return fmt.Sprintf("mfa_setup:%d", userID) }
+func mfaAttemptsKey(userID uint) string  { return fmt.Sprintf("mfa_attempts:%d", userID) }
+func mfaChallengeKey(hash string) string { return "mfa_challenge:" + hash }
+func totpUsedKey(userID uint, code string) string {
+	return fmt.Sprintf("mfa_totp_used:%d:%s", userID, code)
+}
+
+// TOTPSetup 开始绑定时返回给客户端的信息，用户用验证器 App 扫描二维码或手动输入密钥
+type TOTPSetup struct {
+	Secret    string `json:"secret"`
+	URL       string `json:"otpauth_url"`
+	QRCode    string `json:"qr_code"` // PNG 二维码（data URI）
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// BeginTOTPSetup 为用户生成新的 TOTP 密钥，在 MFASetupTTL 内调用 EnableTOTP 确认后才会生效
+func BeginTOTPSetup(user *Model.User) (*TOTPSetup, error) {
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      config.Cfg.MFA.Issuer,
+		AccountName: user.Username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成 TOTP 密钥失败: %w", err)
+	}
+	qr, err := qrCodeDataURI(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.SetString(mfaSetupKey(user.UserID), key.Secret(), MFASetupTTL); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret:    key.Secret(),
+		URL:       key.URL(),
+		QRCode:    qr,
+		ExpiresIn: int(MFASetupTTL.Seconds()),
+	}, nil
+}
+
+// qrCodeDataURI 生成 otpauth URI 的二维码
+func qrCodeDataURI(key *otp.Key) (string, error) {
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return "", fmt.Errorf("生成二维码失败: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("生成二维码失败: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// EnableTOTP 校验验证器 App 生成的验证码并启用两步验证，返回新生成的恢复码（只展示这一次）
+func EnableTOTP(userID uint, code string) ([]string, error) {
+	secret, err := database.GetString(mfaSetupKey(userID))
+	if errors.Is(err, database.ErrNil) {
+		return nil, ErrMFASetupExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTOTP(userID, secret, code); err != nil {
+		return nil, err
+	}
+	sealed, err := secretbox.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Model.User{}).
+			Where("user_id = ? AND mfa_enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"totp_secret": sealed, "mfa_enabled_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMFAAlreadyEnabled
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = database.Delete(mfaSetupKey(userID))
+	return codes, nil
+}
+
+// DisableMFA 关闭两步验证并删除恢复码
+func DisableMFA(userID uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Model.User{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"totp_secret": "", "mfa_enabled_at": nil}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&Model.MFARecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	// 临时数据都会自动过期，清理失败不影响关闭结果
+	if err := clearMFAState(userID); err != nil {
+		slog.Warn("清理两步验证临时数据失败", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+// clearMFAState 删除用户残留的两步验证临时数据：未完成的绑定、错误次数和防重放记录，
+// 避免重新绑定时沿用关闭前的错误次数
+func clearMFAState(userID uint) error {
+	if err := database.DeleteKeys(mfaSetupKey(userID), mfaAttemptsKey(userID)); err != nil {
+		return err
+	}
+	_, err := database.DeleteByPattern(fmt.Sprintf("mfa_totp_used:%d:*", userID))
+	return err
+}
+
+// RegenerateRecoveryCodes 作废所有旧的恢复码并生成新的一组
+func RegenerateRecoveryCodes(userID uint) ([]string, error) {
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes 未使用的恢复码数量
+func RemainingRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := database.DB.Model(&Model.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&Model.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	records := make([]Model.MFARecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = Model.MFARecoveryCode{UserID: userID, CodeHash: session.HashToken(normalizeRecoveryCode(code))}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode 生成形如 abcde-fghij 的恢复码（50 位随机数）
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// VerifyMFACode 校验已启用两步验证的用户提交的验证码：6 位数字按 TOTP 校验，其他按恢复码校验（使用后作废）
+func VerifyMFACode(user *Model.User, code string) error {
+	if !user.MFAEnabled() {
+		return ErrMFANotEnabled
+	}
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		secret, err := secretbox.Open(user.TOTPSecret)
+		if err != nil {
+			return fmt.Errorf("解密用户 %d 的 TOTP 密钥失败: %w", user.UserID, err)
+		}
+		return validateTOTP(user.UserID, secret, code)
+	}
+
+	result := database.DB.Model(&Model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.UserID, session.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// checkMFAAttempts 用户在统计窗口内输错验证码的次数达到上限时返回 ErrMFATooManyAttempts
+func checkMFAAttempts(userID uint) error {
+	value, err := database.GetString(mfaAttemptsKey(userID))
+	if errors.Is(err, database.ErrNil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := strconv.Atoi(value); n >= maxMFAAttempts {
+		return ErrMFATooManyAttempts
+	}
+	return nil
+}
+
+// VerifyMFACodeLimited 校验验证码并限制错误次数（登录第二步、关闭两步验证、重新生成恢复码）：
+// 窗口内错误达到上限后返回 ErrMFATooManyAttempts，防止持有密码或被盗 access token 的人穷举 6 位验证码
+func VerifyMFACodeLimited(user *Model.User, code string) error {
+	key := mfaAttemptsKey(user.UserID)
+	if err := checkMFAAttempts(user.UserID); err != nil {
+		return err
+	}
+
+	err := VerifyMFACode(user, code)
+	if errors.Is(err, ErrMFACodeInvalid) {
+		attempts, incErr := database.IncrementWithTTL(key, MFAAttemptWindow)
+		if incErr != nil {
+			return incErr
+		}
+		if attempts >= maxMFAAttempts {
+			return ErrMFATooManyAttempts
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return database.Delete(key)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTOTP 校验 TOTP 验证码；同一个验证码在有效期内只能使用一次
+func validateTOTP(userID uint, secret, code string) error {
+	ok, err := totp.ValidateCustom(code, secret, time.Now(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      totpSkew,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil || !ok {
+		return ErrMFACodeInvalid
+	}
+
+	// 计数是原子操作，并发提交同一个验证码时只有一个请求能拿到 1
+	key := totpUsedKey(userID, code)
+	uses, err := database.IncrementWithTTL(key, time.Duration(2*totpSkew+1)*30*time.Second)
+	if err != nil {
+		return err
+	}
+	if uses > 1 {
+		return ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// mfaChallenge 密码验证通过、等待两步验证的登录
+type mfaChallenge struct {
+	UserID uint   `json:"user_id"`
+	Device string `json:"device"`
+}
+
+// CreateMFAChallenge 密码（或第三方）验证通过后为启用了两步验证的用户生成临时令牌，
+// 客户端凭此令牌和验证码调用 CompleteMFAChallenge 完成登录。
+// 验证码错误次数已达上限时返回 ErrMFATooManyAttempts，重新登录不能绕过次数限制
+func CreateMFAChallenge(userID uint, device string) (string, error) {
+	if err := checkMFAAttempts(userID); err != nil {
+		return "", err
+	}
+	token, err := session.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(mfaChallenge{UserID: userID, Device: device})
+	if err != nil {
+		return "", err
+	}
+	if err := database.SetString(mfaChallengeKey(session.HashToken(token)), string(data), MFAChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CompleteMFAChallenge 校验临时令牌和两步验证码，成功后令牌作废，返回通过验证的用户和设备名；
+// 错误次数按用户统计，达到上限时令牌作废并返回 ErrMFATooManyAttempts，窗口结束前不能再次登录
+func CompleteMFAChallenge(token, code string) (*Model.User, string, error) {
+	key := mfaChallengeKey(session.HashToken(token))
+	raw, err := database.GetString(key)
+	if errors.Is(err, database.ErrNil) {
+		return nil, "", ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	var challenge mfaChallenge
+	if err := json.Unmarshal([]byte(raw), &challenge); err != nil {
+		return nil, "", ErrMFAChallengeInvalid
+	}
+
+	var user Model.User
+	if err := database.DB.First(&user, challenge.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = database.Delete(key)
+			return nil, "", ErrMFAChallengeInvalid
+		}
+		return nil, "", err
+	}
+	if user.IsBanned() {
+		_ = database.Delete(key)
+		return nil, "", ErrUserBanned
+	}
+
+	if err := VerifyMFACodeLimited(&user, code); err != nil {
+		if errors.Is(err, ErrMFATooManyAttempts) {
+			_ = database.Delete(key)
+		}
+		return nil, "", err
+	}
+
+	if err := database.Delete(key); err != nil {
+		return nil, "", err
+	}
+	return &user, challenge.Device, nil
+}
+
+// MFARequired 用户是否必须启用两步验证（mfa.require_for_admins 开启时的管理员）
+func MFARequired(user *Model.User) bool {
+	return config.Cfg.MFA.RequireForAdmins && user.IsAdmin()
+}
+
+// MFAEnrollmentRequired 用户必须启用两步验证但尚未启用
+func MFAEnrollmentRequired(user *Model.User) bool {
+	return MFARequired(user) && !user.MFAEnabled()
+}
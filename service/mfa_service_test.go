@@ -0,0 +1,127 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/database"
+	"blog/secretbox"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// enableTestMFA 为用户直接写入已启用的 TOTP 密钥
+func enableTestMFA(t *testing.T, user *Model.User) {
+	t.Helper()
+	sealed, err := secretbox.Seal(testTOTPSecret)
+	if err != nil {
+		t.Fatalf("加密 TOTP 密钥失败: %v", err)
+	}
+	if err := database.DB.Model(user).Updates(map[string]interface{}{
+		"totp_secret": sealed, "mfa_enabled_at": time.Now(),
+	}).Error; err != nil {
+		t.Fatalf("启用两步验证失败: %v", err)
+	}
+}
+
+// totpCodes 当前有效的验证码（允许前后各一个时间窗口）和一个不在其中的错误验证码
+func totpCodes(t *testing.T) (valid, wrong string) {
+	t.Helper()
+	now := time.Now()
+	accepted := map[string]bool{}
+	for _, offset := range []time.Duration{-30 * time.Second, 0, 30 * time.Second} {
+		code, err := totp.GenerateCode(testTOTPSecret, now.Add(offset))
+		if err != nil {
+			t.Fatalf("生成验证码失败: %v", err)
+		}
+		accepted[code] = true
+		if offset == 0 {
+			valid = code
+		}
+	}
+	for _, c := range []string{"000000", "111111", "222222", "333333"} {
+		if !accepted[c] {
+			return valid, c
+		}
+	}
+	t.Fatal("找不到错误的验证码")
+	return "", ""
+}
+
+func TestCompleteMFAChallengeSharedBudget(t *testing.T) {
+	user := setupServiceTest(t)
+	enableTestMFA(t, user)
+	valid, wrong := totpCodes(t)
+
+	first, err := CreateMFAChallenge(user.UserID, "")
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge error = %v", err)
+	}
+	for i := 0; i < maxMFAAttempts-2; i++ {
+		if _, _, err := CompleteMFAChallenge(first, wrong); !errors.Is(err, ErrMFACodeInvalid) {
+			t.Fatalf("attempt %d error = %v, want ErrMFACodeInvalid", i+1, err)
+		}
+	}
+
+	// 重新登录拿到新的临时令牌，错误次数继续累计
+	second, err := CreateMFAChallenge(user.UserID, "")
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge error = %v", err)
+	}
+	if _, _, err := CompleteMFAChallenge(second, wrong); !errors.Is(err, ErrMFACodeInvalid) {
+		t.Fatalf("second challenge error = %v, want ErrMFACodeInvalid", err)
+	}
+	if _, _, err := CompleteMFAChallenge(second, wrong); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Fatalf("last attempt error = %v, want ErrMFATooManyAttempts", err)
+	}
+
+	// 达到上限后：不再签发新的临时令牌，已有的令牌即使验证码正确也不能通过
+	if _, err := CreateMFAChallenge(user.UserID, ""); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("CreateMFAChallenge after limit error = %v, want ErrMFATooManyAttempts", err)
+	}
+	if _, _, err := CompleteMFAChallenge(second, valid); !errors.Is(err, ErrMFAChallengeInvalid) {
+		t.Errorf("exhausted challenge error = %v, want ErrMFAChallengeInvalid", err)
+	}
+	if _, _, err := CompleteMFAChallenge(first, valid); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("first challenge after limit error = %v, want ErrMFATooManyAttempts", err)
+	}
+
+	// 已登录后的敏感操作使用同一个计数
+	if err := VerifyMFACodeLimited(user, valid); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("VerifyMFACodeLimited after limit error = %v, want ErrMFATooManyAttempts", err)
+	}
+}
+
+func TestCompleteMFAChallengeResetsBudget(t *testing.T) {
+	user := setupServiceTest(t)
+	enableTestMFA(t, user)
+	valid, wrong := totpCodes(t)
+
+	token, err := CreateMFAChallenge(user.UserID, "laptop")
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge error = %v", err)
+	}
+	for i := 0; i < maxMFAAttempts-1; i++ {
+		if _, _, err := CompleteMFAChallenge(token, wrong); !errors.Is(err, ErrMFACodeInvalid) {
+			t.Fatalf("attempt %d error = %v, want ErrMFACodeInvalid", i+1, err)
+		}
+	}
+	got, device, err := CompleteMFAChallenge(token, valid)
+	if err != nil {
+		t.Fatalf("CompleteMFAChallenge(valid) error = %v", err)
+	}
+	if got.UserID != user.UserID || device != "laptop" {
+		t.Errorf("CompleteMFAChallenge = user %d, device %q", got.UserID, device)
+	}
+
+	// 验证通过后令牌作废，错误次数清零
+	if _, _, err := CompleteMFAChallenge(token, valid); !errors.Is(err, ErrMFAChallengeInvalid) {
+		t.Errorf("reused challenge error = %v, want ErrMFAChallengeInvalid", err)
+	}
+	if ok, _ := database.Exists(mfaAttemptsKey(user.UserID)); ok {
+		t.Error("mfa_attempts key kept after successful verification")
+	}
+}
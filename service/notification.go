@@ -0,0 +1,55 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/database"
+	"blog/mail"
+	"log/slog"
+	"strings"
+)
+
+// maxNotificationExcerpt 通知邮件中评论内容的最大长度（字符）
+const maxNotificationExcerpt = 500
+
+// NotifyNewComment 通知文章作者有新评论，在后台发送；
+// 作者自己的评论、作者未设置邮箱或已被封禁时不发送
+func NotifyNewComment(comment *Model.Comment, content *Model.Content) {
+	if comment.UserID == content.UserID {
+		return
+	}
+
+	var author Model.User
+	if err := database.DB.Select("user_id", "username", "email", "language", "banned_at").
+		First(&author, content.UserID).Error; err != nil {
+		slog.Warn("查询文章作者失败，评论通知未发送", "content_id", content.ID, "error", err)
+		return
+	}
+	if author.Email == "" || author.IsBanned() {
+		return
+	}
+
+	var commenter Model.User
+	if err := database.DB.Select("user_id", "username").First(&commenter, comment.UserID).Error; err != nil {
+		slog.Warn("查询评论者失败，评论通知未发送", "comment_id", comment.ID, "error", err)
+		return
+	}
+
+	excerpt := []rune(strings.TrimSpace(comment.CommentText))
+	if len(excerpt) > maxNotificationExcerpt {
+		excerpt = append(excerpt[:maxNotificationExcerpt], []rune("…")...)
+	}
+
+	mail.SendAsync(mail.Request{
+		To:       author.Email,
+		Template: mail.TemplateCommentNotification,
+		Lang:     author.Language,
+		Data: map[string]any{
+			"Username":     author.Username,
+			"Commenter":    commenter.Username,
+			"ContentID":    content.ID,
+			"ContentTitle": content.Title,
+			"CommentText":  string(excerpt),
+		},
+		Purpose: mail.TemplateCommentNotification,
+	})
+}
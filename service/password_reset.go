@@ -0,0 +1,113 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/database"
+	"blog/mail"
+	"blog/policy"
+	"blog/session"
+	"blog/utils"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetTTL 找回密码验证码的有效期
+const PasswordResetTTL = 15 * time.Minute
+
+// RequestPasswordReset 为邮箱对应的用户生成找回密码验证码并在后台发送，邮件优先使用用户设置的语言，其次为 lang。
+// 邮箱未注册、用户被封禁、发送过于频繁或邮件发送失败时同样返回 nil；邮件在后台发送，
+// 响应时间不包含 SMTP 往返，避免通过返回值或响应时间探测邮箱是否注册
+func RequestPasswordReset(email, lang string) error {
+	var user Model.User
+	err := database.DB.Select("user_id", "username", "email", "language", "banned_at").Where("LOWER(email) = ?", normalizeEmail(email)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsBanned() {
+		return nil
+	}
+
+	code := Utils.GenerateRandomCode(8)
+	if err := IssueCode(PurposePasswordReset, user.Email, code, PasswordResetTTL); err != nil {
+		if errors.Is(err, ErrCodeTooFrequent) {
+			return nil
+		}
+		return err
+	}
+
+	if user.Language != "" {
+		lang = user.Language
+	}
+	mail.SendAsync(mail.Request{
+		To:       user.Email,
+		Template: mail.TemplatePasswordReset,
+		Lang:     lang,
+		Data: map[string]any{
+			"Username":       user.Username,
+			"Code":           code,
+			"ExpiresMinutes": int(PasswordResetTTL.Minutes()),
+		},
+		Purpose:   string(PurposePasswordReset),
+		Code:      code,
+		ExpiresAt: time.Now().Add(PasswordResetTTL),
+		// 删除未送达的验证码，用户可以立即重试；失败原因已写入投递记录
+		OnFailure: func(error) {
+			if err := RevokeCode(PurposePasswordReset, user.Email); err != nil {
+				slog.Warn("删除未送达的验证码失败", "user_id", user.UserID, "error", err)
+			}
+		},
+	})
+	return nil
+}
+
+// ResetPassword 校验找回密码验证码并设置新密码，成功后注销该用户在所有设备上的登录并解除登录锁定。
+// 新密码不符合 password_policy 时返回 *policy.Error，验证码不会被消耗，可以换一个密码重试
+func ResetPassword(email, code, newPassword string) error {
+	// 先校验验证码再检查密码规则，避免不持有验证码的人通过规则探测用户名
+	if err := CheckCode(PurposePasswordReset, email, code); err != nil {
+		return err
+	}
+
+	var user Model.User
+	if err := database.DB.Where("LOWER(email) = ?", normalizeEmail(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCodeInvalid
+		}
+		return err
+	}
+	if violations := policy.Password(newPassword, user.Username); len(violations) > 0 {
+		return &policy.Error{Violations: violations}
+	}
+	if err := ConsumeCode(PurposePasswordReset, email, code); err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := database.DB.Model(&user).Update("password", hashed).Error; err != nil {
+		return err
+	}
+
+	if _, err := session.RevokeAll(user.UserID, ""); err != nil {
+		slog.Error("重置密码后注销会话失败", "user_id", user.UserID, "error", err)
+	}
+	// 能收到邮件说明是账号本人，同时解除登录锁定
+	if err := UnlockAccount(&user); err != nil {
+		slog.Error("重置密码后解除登录锁定失败", "user_id", user.UserID, "error", err)
+	}
+	return nil
+}
+
+// normalizeEmail 邮箱比较不区分大小写，与注册时的重复检查和验证码的键保持一致
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
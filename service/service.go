@@ -2,7 +2,12 @@ package service
 
 import (
 	"blog/database"
+	"blog/mail"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 )
 
@@ -10,59 +15,185 @@ type EmailService struct{}
 
 var defaultEmailService = &EmailService{}
 
-// GenerateAndStoreVerificationCode 生成并存储验证码
-func GenerateAndStoreVerificationCode(email string) (string, error) {
-	// 检查是否存在未过期的验证码
-	exists, _ := CheckVerificationCodeExists(email)
-	if exists {
-		return "", fmt.Errorf("验证码已发送，请等待之前的验证码过期")
+// CodePurpose 验证码用途，不同用途的验证码互不通用
+type CodePurpose string
+
+const (
+	PurposeVerify        CodePurpose = "verify"         // 通用邮箱验证
+	PurposePasswordReset CodePurpose = "password_reset" // 找回密码
+)
+
+// maxCodeAttempts 每个验证码允许的错误次数，超过后验证码作废，需要重新获取
+const maxCodeAttempts = 5
+
+// 验证码相关的业务错误，控制层据此映射错误码
+var (
+	ErrCodeTooFrequent      = errors.New("验证码发送过于频繁，请稍后再试")
+	ErrCodeInvalid          = errors.New("验证码错误或已过期")
+	ErrCodeAttemptsExceeded = errors.New("验证码错误次数过多，请重新获取")
+)
+
+// CodeResendInterval 同一邮箱同一用途两次发送验证码的最小间隔；重新发送后之前的验证码失效
+const CodeResendInterval = time.Minute
+
+// codeKey 验证码的KV键：email:<purpose>:<email>，
+// 错误次数记录在 <键>:attempts，重新发送的冷却时间记录在 <键>:cooldown
+func codeKey(purpose CodePurpose, email string) string {
+	return fmt.Sprintf("email:%s:%s", purpose, strings.ToLower(strings.TrimSpace(email)))
+}
+
+// IssueCode 为邮箱生成指定用途的验证码，覆盖之前未使用的验证码；
+// 距上次发送不足 CodeResendInterval 时返回 ErrCodeTooFrequent
+func IssueCode(purpose CodePurpose, email, code string, ttl time.Duration) error {
+	key := codeKey(purpose, email)
+	if exists, err := database.Exists(key + ":cooldown"); err != nil {
+		return err
+	} else if exists {
+		return ErrCodeTooFrequent
 	}
 
-	// 生成新验证码
-	code := database.GenerateMixedCode(10)
+	if err := database.SetString(key, code, ttl); err != nil {
+		return fmt.Errorf("存储验证码失败: %v", err)
+	}
+	if err := database.SetString(key+":cooldown", "1", min(CodeResendInterval, ttl)); err != nil {
+		return err
+	}
+	// 新验证码重新计算错误次数
+	return database.Delete(key + ":attempts")
+}
+
+// ResendWait 距离可以重新发送验证码还需等待的时间，无需等待时返回 0
+func ResendWait(purpose CodePurpose, email string) time.Duration {
+	ttl, err := database.GetTTL(codeKey(purpose, email) + ":cooldown")
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// RevokeCode 删除验证码（例如邮件未能送达），同时清除冷却时间，允许立即重新发送
+func RevokeCode(purpose CodePurpose, email string) error {
+	key := codeKey(purpose, email)
+	return database.DeleteKeys(key, key+":attempts", key+":cooldown")
+}
+
+// ConsumeCode 校验并使用验证码：成功后验证码立即失效；错误次数超过上限时验证码作废
+func ConsumeCode(purpose CodePurpose, email, code string) error {
+	if err := CheckCode(purpose, email, code); err != nil {
+		return err
+	}
+
+	key := codeKey(purpose, email)
+	if err := database.DeleteKeys(key, key+":attempts"); err != nil {
+		return err
+	}
+	if err := mail.MarkUsed(string(purpose), email, code); err != nil {
+		slog.Warn("标记邮件验证码已使用失败", "purpose", purpose, "email", email, "error", err)
+	}
+	return nil
+}
 
-	// 使用Redis存储验证码
-	key := fmt.Sprintf("email:verify:%s", email)
-	err := database.SetString(key, code, 5*time.Minute)
+// CheckCode 校验验证码但不使用，用于提交表单前的预校验；错误次数与 ConsumeCode 共同计算
+func CheckCode(purpose CodePurpose, email, code string) error {
+	key := codeKey(purpose, email)
+
+	stored, err := database.GetString(key)
+	if errors.Is(err, database.ErrNil) {
+		return ErrCodeInvalid
+	}
 	if err != nil {
-		return "", fmt.Errorf("存储验证码失败: %v", err)
+		return err
+	}
+
+	if stored != code {
+		// 错误次数与验证码同时过期；验证码写入时总带有过期时间，取不到时按重发间隔计算
+		ttl := remaining(key)
+		if ttl <= 0 {
+			ttl = CodeResendInterval
+		}
+		attempts, err := database.IncrementWithTTL(key+":attempts", ttl)
+		if err != nil {
+			return err
+		}
+		if attempts >= maxCodeAttempts {
+			_ = database.DeleteKeys(key, key+":attempts")
+			return ErrCodeAttemptsExceeded
+		}
+		return ErrCodeInvalid
 	}
+	return nil
+}
+
+// CodeTTL 获取验证码剩余有效期，不存在时返回值小于等于0
+func CodeTTL(purpose CodePurpose, email string) (time.Duration, error) {
+	return database.GetTTL(codeKey(purpose, email))
+}
+
+// VerificationCodeTTL 邮箱验证码的有效期
+const VerificationCodeTTL = 5 * time.Minute
 
+// GenerateAndStoreVerificationCode 生成并存储验证码
+func GenerateAndStoreVerificationCode(email string) (string, error) {
+	code := database.GenerateMixedCode(10)
+	if err := IssueCode(PurposeVerify, email, code, VerificationCodeTTL); err != nil {
+		return "", err
+	}
 	return code, nil
 }
 
-// VerifyEmailCode 验证邮箱验证码
-func VerifyEmailCode(email, code string) (bool, error) {
-	key := fmt.Sprintf("email:verify:%s", email)
+// SendVerificationCode 生成验证码并发送验证邮件，lang 为邮件语言。
+// 发送失败时删除验证码，用户可以立即重新获取
+func SendVerificationCode(ctx context.Context, email, lang string) error {
+	code, err := GenerateAndStoreVerificationCode(email)
+	if err != nil {
+		return err
+	}
 
-	storedCode, err := database.GetString(key)
+	err = mail.Send(ctx, mail.Request{
+		To:       email,
+		Template: mail.TemplateVerification,
+		Lang:     lang,
+		Data: map[string]any{
+			"Code":           code,
+			"ExpiresMinutes": int(VerificationCodeTTL.Minutes()),
+		},
+		Purpose:   string(PurposeVerify),
+		Code:      code,
+		ExpiresAt: time.Now().Add(VerificationCodeTTL),
+	})
 	if err != nil {
-		return false, nil // 验证码不存在或已过期
+		if delErr := RevokeCode(PurposeVerify, email); delErr != nil {
+			slog.Warn("删除未送达的验证码失败", "email", email, "error", delErr)
+		}
+		return err
 	}
+	return nil
+}
 
-	if storedCode == code {
-		// 验证成功后立即删除验证码
-		_ = database.Delete(key)
+// VerifyEmailCode 验证邮箱验证码
+func VerifyEmailCode(email, code string) (bool, error) {
+	err := ConsumeCode(PurposeVerify, email, code)
+	switch {
+	case err == nil:
 		return true, nil
+	case errors.Is(err, ErrCodeInvalid), errors.Is(err, ErrCodeAttemptsExceeded):
+		return false, nil
+	default:
+		return false, err
 	}
-
-	return false, nil
 }
 
 // CheckVerificationCodeExists 检查验证码是否存在
 func CheckVerificationCodeExists(email string) (bool, error) {
-	key := fmt.Sprintf("email:verify:%s", email)
-	return database.Exists(key)
+	return database.Exists(codeKey(PurposeVerify, email))
 }
 
 // GetVerificationCodeTTL 获取验证码剩余有效期
 func GetVerificationCodeTTL(email string) (time.Duration, error) {
-	key := fmt.Sprintf("email:verify:%s", email)
-	return database.GetTTL(key)
+	return CodeTTL(PurposeVerify, email)
 }
 
 // DeleteVerificationCode 删除验证码
 func DeleteVerificationCode(email string) error {
-	key := fmt.Sprintf("email:verify:%s", email)
-	return database.Delete(key)
+	return database.Delete(codeKey(PurposeVerify, email))
 }
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/database"
+	"blog/session"
+	"blog/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// 刷新令牌相关的业务错误，控制层据此映射错误码
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token 无效或已过期")
+	ErrRefreshTokenReused  = errors.New("refresh token 被重复使用，已注销该会话")
+	ErrUserBanned          = errors.New("用户已被封禁")
+)
+
+// TokenPair 登录成功后签发的令牌对
+//
+// access token 为短期 JWT，放在 Authorization 头中访问接口；
+// refresh token 为服务端保存的随机串，只能使用一次，每次刷新都会换发新的令牌对。
+// 每次登录创建一个会话（见 session 包），同一会话换发出的 refresh token 中任何一个旧 token
+// 被重复使用时视为泄露，整个会话立即注销
+type TokenPair struct {
+	AccessToken      string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`         // access token 有效期（秒）
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // refresh token 有效期（秒）
+	SessionID        string `json:"session_id"`
+}
+
+// KV 键：
+//
+//	refresh_token:<hash>       refresh token -> "<sessionID>:<userID>"
+//	refresh_token:used:<hash>  refresh token 的使用次数
+func refreshTokenKey(hash string) string { return "refresh_token:" + hash }
+func refreshUsedKey(hash string) string  { return "refresh_token:used:" + hash }
+
+// IssueTokenPair 登录成功后为客户端创建新会话并签发令牌对
+func IssueTokenPair(userID uint, username string, client session.Client) (*TokenPair, error) {
+	sess, err := session.Create(userID, client)
+	if err != nil {
+		return nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+	return issueTokenPair(userID, username, sess.ID, client)
+}
+
+// RefreshTokenPair 使用 refresh token 换发新的令牌对，旧的 refresh token 随即失效
+func RefreshTokenPair(refreshToken string, client session.Client) (*TokenPair, error) {
+	hash := session.HashToken(refreshToken)
+
+	record, err := database.GetString(refreshTokenKey(hash))
+	if errors.Is(err, database.ErrNil) {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	sessionID, owner, ok := strings.Cut(record, ":")
+	userID, parseErr := strconv.ParseUint(owner, 10, 32)
+	if !ok || parseErr != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 会话已被注销（登出、远程注销、检测到重复使用等）
+	if _, err := session.Get(sessionID); errors.Is(err, session.ErrNotFound) {
+		return nil, ErrRefreshTokenInvalid
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 计数是原子操作，并发刷新时只有一个请求能拿到 1
+	uses, err := database.IncrementWithTTL(refreshUsedKey(hash), config.Cfg.JWT.RefreshExpire)
+	if err != nil {
+		return nil, err
+	}
+	if uses > 1 {
+		slog.Warn("检测到 refresh token 重复使用，注销该会话", "user_id", userID, "session_id", sessionID)
+		if err := session.Revoke(uint(userID), sessionID); err != nil && !errors.Is(err, session.ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	var user Model.User
+	if err := database.DB.Select("user_id", "username", "banned_at").First(&user, userID).Error; err != nil {
+		// 用户已被删除
+		_ = session.Revoke(uint(userID), sessionID)
+		return nil, ErrRefreshTokenInvalid
+	}
+	if user.IsBanned() {
+		_ = session.Revoke(uint(userID), sessionID)
+		return nil, ErrUserBanned
+	}
+
+	return issueTokenPair(user.UserID, user.Username, sessionID, client)
+}
+
+func issueTokenPair(userID uint, username, sessionID string, client session.Client) (*TokenPair, error) {
+	cfg := config.Cfg.JWT
+
+	accessToken, err := utils.GenerateToken(int64(userID), username, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("生成 access token 失败: %w", err)
+	}
+	refreshToken, err := session.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.Activate(sessionID, accessToken, client); err != nil {
+		return nil, err
+	}
+	record := sessionID + ":" + strconv.FormatUint(uint64(userID), 10)
+	if err := database.SetString(refreshTokenKey(session.HashToken(refreshToken)), record, cfg.RefreshExpire); err != nil {
+		return nil, fmt.Errorf("存储 refresh token 失败: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(cfg.Expire.Seconds()),
+		RefreshExpiresIn: int64(cfg.RefreshExpire.Seconds()),
+		SessionID:        sessionID,
+	}, nil
+}
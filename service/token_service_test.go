@@ -0,0 +1,127 @@
+package service
+
+import (
+	"blog/Model"
+	"blog/config"
+	"blog/database"
+	"blog/migrations"
+	"blog/session"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupServiceTest 使用临时 SQLite 数据库和内存 KV 替换全局的 database.DB / database.Store
+func setupServiceTest(t *testing.T) *Model.User {
+	t.Helper()
+	config.Cfg = config.Default()
+	config.Cfg.JWT.Secret = "0123456789abcdef0123"
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	kv := database.NewMemoryKV()
+	database.DB, database.Store = db, kv
+	t.Cleanup(func() {
+		kv.Close()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		database.DB, database.Store = nil, nil
+	})
+
+	user := &Model.User{Username: "alice", Password: "x", Email: "alice@example.com", Role: Model.RoleAuthor}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+func TestRefreshTokenPair(t *testing.T) {
+	user := setupServiceTest(t)
+	client := session.Client{IP: "127.0.0.1", UserAgent: "test"}
+
+	first, err := IssueTokenPair(user.UserID, user.Username, client)
+	if err != nil {
+		t.Fatalf("IssueTokenPair error = %v", err)
+	}
+	second, err := RefreshTokenPair(first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("RefreshTokenPair error = %v", err)
+	}
+	if second.SessionID != first.SessionID {
+		t.Errorf("refresh changed session %s -> %s", first.SessionID, second.SessionID)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh returned the same refresh token")
+	}
+
+	if _, err := RefreshTokenPair("not-a-token", client); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("RefreshTokenPair(unknown) error = %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestRefreshTokenPairReuse(t *testing.T) {
+	user := setupServiceTest(t)
+	client := session.Client{IP: "127.0.0.1", UserAgent: "test"}
+
+	first, err := IssueTokenPair(user.UserID, user.Username, client)
+	if err != nil {
+		t.Fatalf("IssueTokenPair error = %v", err)
+	}
+	second, err := RefreshTokenPair(first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("RefreshTokenPair error = %v", err)
+	}
+
+	// 旧 token 再次使用视为泄露，整个会话被注销
+	if _, err := RefreshTokenPair(first.RefreshToken, client); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshTokenPair(reused) error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := session.Get(first.SessionID); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("session.Get after reuse error = %v, want ErrNotFound", err)
+	}
+	// 同一会话中最新换发的 token 也随之失效
+	if _, err := RefreshTokenPair(second.RefreshToken, client); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("RefreshTokenPair(latest) error = %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	// 其他会话不受影响
+	other, err := IssueTokenPair(user.UserID, user.Username, client)
+	if err != nil {
+		t.Fatalf("IssueTokenPair error = %v", err)
+	}
+	if _, err := RefreshTokenPair(other.RefreshToken, client); err != nil {
+		t.Errorf("RefreshTokenPair(other session) error = %v", err)
+	}
+}
+
+func TestRefreshTokenPairBannedUser(t *testing.T) {
+	user := setupServiceTest(t)
+	client := session.Client{IP: "127.0.0.1", UserAgent: "test"}
+
+	pair, err := IssueTokenPair(user.UserID, user.Username, client)
+	if err != nil {
+		t.Fatalf("IssueTokenPair error = %v", err)
+	}
+	if err := database.DB.Model(user).Update("banned_at", time.Now()).Error; err != nil {
+		t.Fatalf("封禁用户失败: %v", err)
+	}
+	if _, err := RefreshTokenPair(pair.RefreshToken, client); !errors.Is(err, ErrUserBanned) {
+		t.Errorf("RefreshTokenPair(banned) error = %v, want ErrUserBanned", err)
+	}
+	if _, err := session.Get(pair.SessionID); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("session.Get after ban error = %v, want ErrNotFound", err)
+	}
+}
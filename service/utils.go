@@ -26,43 +26,6 @@ func (u *ControllerUtils) ValidateEmail(email string) bool {
 	return reg.MatchString(email)
 }
 
-// ValidatePassword 验证密码强度
-func (u *ControllerUtils) ValidatePassword(password string) (bool, string) {
-	if len(password) < 6 {
-		return false, "密码长度至少6位"
-	}
-	if len(password) > 20 {
-		return false, "密码长度不能超过20位"
-	}
-
-	hasUpper := regexp.MustCompile(`[A-Z]`).MatchString(password)
-	hasLower := regexp.MustCompile(`[a-z]`).MatchString(password)
-	hasNumber := regexp.MustCompile(`[0-9]`).MatchString(password)
-
-	if !hasUpper || !hasLower || !hasNumber {
-		return false, "密码必须包含大写字母、小写字母和数字"
-	}
-
-	return true, ""
-}
-
-// ValidateUsername 验证用户名
-func (u *ControllerUtils) ValidateUsername(username string) (bool, string) {
-	if len(username) < 3 {
-		return false, "用户名长度至少3位"
-	}
-	if len(username) > 20 {
-		return false, "用户名长度不能超过20位"
-	}
-
-	pattern := `^[a-zA-Z0-9_]+$`
-	if !regexp.MustCompile(pattern).MatchString(username) {
-		return false, "用户名只能包含字母、数字和下划线"
-	}
-
-	return true, ""
-}
-
 // GenerateRandomCode 生成随机验证码（使用utils包中的实现）
 func (u *ControllerUtils) GenerateRandomCode(length int) string {
 	// 导入utils包，使用现有的实现
@@ -92,21 +55,9 @@ func (u *ControllerUtils) ParseIDFromParam(c *gin.Context, paramName string) (ui
 }
 
 // GetClientIP 获取客户端IP地址
+// 只有直连地址属于 server.trusted_proxies 时才采信 X-Forwarded-For / X-Real-IP，
+// 否则客户端可以伪造这些头绕过按 IP 的限流
 func (u *ControllerUtils) GetClientIP(c *gin.Context) string {
-	// 检查X-Forwarded-For头
-	xff := c.GetHeader("X-Forwarded-For")
-	if xff != "" {
-		ips := strings.Split(xff, ",")
-		return strings.TrimSpace(ips[0])
-	}
-
-	// 检查X-Real-IP头
-	xri := c.GetHeader("X-Real-IP")
-	if xri != "" {
-		return xri
-	}
-
-	// 返回RemoteAddr
 	return c.ClientIP()
 }
 
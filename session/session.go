@@ -0,0 +1,332 @@
+// Package session 登录会话：每次登录（密码或第三方）创建一个会话，
+// 同一用户可以在多个设备上同时登录，每个设备各自持有 access token 和 refresh token。
+// 会话保存在 KV 存储中，删除会话即让该设备上的令牌全部失效
+package session
+
+import (
+	"blog/config"
+	"blog/database"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 会话不存在、已过期或已被注销
+var ErrNotFound = errors.New("会话不存在或已失效")
+
+// touchInterval 最近活跃时间的更新间隔，避免每个请求都写一次 KV
+const touchInterval = time.Minute
+
+// Session 一个登录会话（一个设备）
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Client 发起请求的客户端信息
+type Client struct {
+	Device    string // 客户端自报的设备名，为空时根据 User-Agent 推断
+	IP        string
+	UserAgent string
+}
+
+// record KV 中保存的会话，额外记录当前 access token 的哈希
+type record struct {
+	Session
+	AccessHash string `json:"access_hash"`
+}
+
+// KV 键：
+//
+//	session:<id>           会话记录（JSON），过期时间与 refresh token 一致
+//	user_sessions:<userID> 用户的会话索引（哈希，字段为会话ID，值为最近活跃时间的 Unix 秒）
+//
+// 最近活跃时间只写在索引里：校验令牌时不重写会话记录，避免覆盖并发刷新写入的新 access token 哈希
+func sessionKey(id string) string     { return "session:" + id }
+func userIndexKey(userID uint) string { return fmt.Sprintf("user_sessions:%d", userID) }
+
+// sessionTTL 会话与 refresh token 同时过期
+func sessionTTL() time.Duration { return config.Cfg.JWT.RefreshExpire }
+
+// Create 为用户创建新会话；超过 session.max_per_user 时注销最久未活跃的会话
+func Create(userID uint, client Client) (*Session, error) {
+	if limit := config.Cfg.Session.MaxPerUser; limit > 0 {
+		sessions, err := List(userID)
+		if err != nil {
+			return nil, err
+		}
+		// List 按最近活跃时间倒序，保留最新的 limit-1 个，为新会话腾出位置
+		for i := limit - 1; i < len(sessions); i++ {
+			if err := Revoke(userID, sessions[i].ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	id, err := RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	device := client.Device
+	if device == "" {
+		device = GuessDevice(client.UserAgent)
+	}
+	rec := &record{Session: Session{
+		ID:         id,
+		UserID:     userID,
+		Device:     device,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}}
+	if err := save(rec, sessionTTL()); err != nil {
+		return nil, err
+	}
+	if err := touch(userID, id, now); err != nil {
+		return nil, err
+	}
+	if err := database.SetExpire(userIndexKey(userID), sessionTTL()); err != nil {
+		return nil, err
+	}
+	return &rec.Session, nil
+}
+
+// Get 读取会话
+func Get(id string) (*Session, error) {
+	rec, err := load(id)
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Session, nil
+}
+
+// Activate 会话签发了新的 access token（登录或刷新）：记录令牌哈希、更新客户端信息，
+// 并重新计算过期时间。之前签发的 access token 随即失效
+func Activate(id, accessToken string, client Client) error {
+	rec, err := load(id)
+	if err != nil {
+		return err
+	}
+	rec.AccessHash = HashToken(accessToken)
+	rec.LastSeenAt = time.Now()
+	if client.IP != "" {
+		rec.IP = client.IP
+	}
+	if client.UserAgent != "" {
+		rec.UserAgent = client.UserAgent
+	}
+	if err := save(rec, sessionTTL()); err != nil {
+		return err
+	}
+	if err := touch(rec.UserID, id, rec.LastSeenAt); err != nil {
+		return err
+	}
+	return database.SetExpire(userIndexKey(rec.UserID), sessionTTL())
+}
+
+// Authenticate 校验 access token 是否为会话当前有效的令牌，并更新最近活跃时间
+func Authenticate(id string, userID uint, accessToken string) (*Session, error) {
+	rec, err := load(id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.UserID != userID || rec.AccessHash != HashToken(accessToken) {
+		return nil, ErrNotFound
+	}
+
+	if value, err := database.GetHash(userIndexKey(userID), id); err == nil {
+		rec.LastSeenAt = lastSeen(rec.LastSeenAt, value)
+	}
+	if time.Since(rec.LastSeenAt) >= touchInterval {
+		rec.LastSeenAt = time.Now()
+		_ = touch(userID, id, rec.LastSeenAt)
+	}
+	return &rec.Session, nil
+}
+
+// touch 在会话索引中记录最近活跃时间
+func touch(userID uint, id string, at time.Time) error {
+	return database.SetHash(userIndexKey(userID), id, strconv.FormatInt(at.Unix(), 10))
+}
+
+// lastSeen 会话记录中的时间与索引中记录的时间取较晚者
+func lastSeen(recorded time.Time, indexValue string) time.Time {
+	sec, err := strconv.ParseInt(indexValue, 10, 64)
+	if err != nil {
+		return recorded
+	}
+	if t := time.Unix(sec, 0); t.After(recorded) {
+		return t
+	}
+	return recorded
+}
+
+// List 返回用户的全部有效会话，按最近活跃时间倒序；顺带清理索引中已过期的会话
+func List(userID uint) ([]Session, error) {
+	index, err := database.GetAllHash(userIndexKey(userID))
+	if err != nil && !errors.Is(err, database.ErrNil) {
+		return nil, err
+	}
+	if len(index) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(index))
+	for id := range index {
+		keys = append(keys, sessionKey(id))
+	}
+	values, err := database.GetStrings(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	var stale []string
+	for id := range index {
+		raw, ok := values[sessionKey(id)]
+		if !ok {
+			stale = append(stale, id)
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			stale = append(stale, id)
+			continue
+		}
+		rec.LastSeenAt = lastSeen(rec.LastSeenAt, index[id])
+		sessions = append(sessions, rec.Session)
+	}
+	if len(stale) > 0 {
+		_ = database.DeleteHash(userIndexKey(userID), stale...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// Revoke 注销用户的某个会话，会话不存在或不属于该用户时返回 ErrNotFound
+func Revoke(userID uint, id string) error {
+	rec, err := load(id)
+	if err != nil {
+		return err
+	}
+	if rec.UserID != userID {
+		return ErrNotFound
+	}
+	if err := database.Delete(sessionKey(id)); err != nil {
+		return err
+	}
+	return database.DeleteHash(userIndexKey(userID), id)
+}
+
+// RevokeAll 注销用户的全部会话，except 非空时保留该会话（例如当前设备），返回注销的数量
+func RevokeAll(userID uint, except string) (int, error) {
+	index, err := database.GetAllHash(userIndexKey(userID))
+	if err != nil && !errors.Is(err, database.ErrNil) {
+		return 0, err
+	}
+
+	var keys, ids []string
+	for id := range index {
+		if id == except {
+			continue
+		}
+		keys = append(keys, sessionKey(id))
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// 只统计仍然有效的会话
+	values, err := database.GetStrings(keys)
+	if err != nil {
+		return 0, err
+	}
+	if err := database.DeleteKeys(keys...); err != nil {
+		return 0, err
+	}
+	if err := database.DeleteHash(userIndexKey(userID), ids...); err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+func load(id string) (*record, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+	raw, err := database.GetString(sessionKey(id))
+	if errors.Is(err, database.ErrNil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec record
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %w", err)
+	}
+	return &rec, nil
+}
+
+func save(rec *record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return database.SetString(sessionKey(rec.ID), string(data), ttl)
+}
+
+// RandomToken 生成 n 字节的随机串（base64url 编码）
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken 服务端只保存令牌的哈希，KV 数据泄露时无法直接拿来使用
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GuessDevice 根据 User-Agent 粗略推断设备类型，用于会话列表展示
+func GuessDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return "未知设备"
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "其他设备"
+	}
+}
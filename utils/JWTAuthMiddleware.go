@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"blog/Model"
 	"blog/constants"
 	"blog/database"
-	"fmt"
+	"blog/i18n"
+	"blog/logger"
+	"blog/session"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +27,7 @@ func jwtAuthMiddleware(required bool) gin.HandlerFunc {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			if required {
-				constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "missing Authorization header"})
+				constants.SendResponse(c, constants.AuthTokenMissing, nil)
 				c.Abort()
 				return
 			}
@@ -36,7 +39,7 @@ func jwtAuthMiddleware(required bool) gin.HandlerFunc {
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
 			if required {
-				constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "invalid Authorization header"})
+				constants.SendResponse(c, constants.AuthTokenMalformed, nil)
 				c.Abort()
 				return
 			}
@@ -48,7 +51,7 @@ func jwtAuthMiddleware(required bool) gin.HandlerFunc {
 		claims, err := ParseToken(token)
 		if err != nil {
 			if required {
-				constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "invalid token"})
+				constants.SendResponse(c, constants.AuthTokenInvalid, nil)
 				c.Abort()
 				return
 			}
@@ -56,12 +59,39 @@ func jwtAuthMiddleware(required bool) gin.HandlerFunc {
 			return
 		}
 
-		// 可选：校验 token 是否在 Redis 中（用于支持主动登出 / 单设备登录等）
-		tokenKey := fmt.Sprintf("user_token:%d", claims.UserID)
-		stored, err := database.GetString(tokenKey)
-		if err != nil || stored != token {
+		// 已登出或被吊销的 token；KV 不可用时无法确认，按已吊销处理
+		if revoked, err := IsTokenRevoked(claims.ID); claims.ID == "" || err != nil || revoked {
 			if required {
-				constants.SendResponse(c, constants.UserUnauthorized, gin.H{"error": "token expired or revoked"})
+				constants.SendResponse(c, constants.AuthTokenRevoked, nil)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// 校验 token 是否为所属会话当前的 access token（会话被注销或令牌已刷新时失效）
+		if _, err := session.Authenticate(claims.SessionID, uint(claims.UserID), token); err != nil {
+			if required {
+				constants.SendResponse(c, constants.AuthTokenRevoked, nil)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// 用户已删除或被封禁（两者都会注销全部会话，这里再检查一次，避免注销失败时仍能访问）
+		var user Model.User
+		var status constants.StatusCode
+		if err := database.DB.Select("user_id", "language", "banned_at").First(&user, claims.UserID).Error; err != nil {
+			status = constants.AuthTokenRevoked
+		} else if user.IsBanned() {
+			status = constants.AuthUserBanned
+		}
+		if status != nil {
+			if required {
+				constants.SendResponse(c, status, nil)
 				c.Abort()
 				return
 			}
@@ -72,6 +102,12 @@ func jwtAuthMiddleware(required bool) gin.HandlerFunc {
 		// 将用户信息放入上下文，供 handler 使用
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Set("claims", claims)
+		logger.SetUserID(c.Request.Context(), claims.UserID)
+
+		// 用户设置了语言偏好时覆盖 Accept-Language 的协商结果
+		i18n.SetUserPreference(c, user.Language)
 		c.Next()
 	}
 }
@@ -1,16 +1,15 @@
 package utils
 
 import (
-	"fmt"
+	"log/slog"
 	"net"
-	"os"
 )
 
 func GetUserIp() {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		slog.Error("获取本机网络地址失败", "error", err)
+		return
 	}
-	fmt.Print(addrs)
+	slog.Info("本机网络地址", "addrs", addrs)
 }
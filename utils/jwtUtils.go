@@ -1,44 +1,57 @@
 package utils
 
 import (
+	"blog/config"
+	"blog/database"
+	"blog/session"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your-secret-key") // 应该从配置文件读取
+// jwtSecret 从配置中读取签名密钥
+func jwtSecret() []byte {
+	return []byte(config.Cfg.JWT.Secret)
+}
 
 // Claims JWT载荷
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"` // 所属登录会话，见 session 包
 	jwt.RegisteredClaims
 }
 
 type JWTUtil struct {
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID int64, username string) (string, error) {
+// GenerateToken 生成JWT token（access token）
+func GenerateToken(userID int64, username, sessionID string) (string, error) {
+	jti, err := session.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
+			ID:        jti,                                                       // 用于吊销单个 token
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.Cfg.JWT.Expire)), // 过期时间由配置决定
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(jwtSecret())
 }
 
 // ParseToken 解析JWT token
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return jwtSecret(), nil
 	})
 
 	if err != nil {
@@ -52,13 +65,24 @@ func ParseToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken 刷新token
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ParseToken(tokenString)
-	if err != nil {
-		return "", err
+// revokedTokenKey 吊销列表中的键
+func revokedTokenKey(jti string) string {
+	return "revoked_token:" + jti
+}
+
+// RevokeToken 将 token 加入吊销列表，保留到 token 自然过期为止
+func RevokeToken(claims *Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
 	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return database.SetString(revokedTokenKey(claims.ID), "1", ttl)
+}
 
-	// 生成新的token
-	return GenerateToken(claims.UserID, claims.Username)
+// IsTokenRevoked 检查 token 是否已被吊销
+func IsTokenRevoked(jti string) (bool, error) {
+	return database.Exists(revokedTokenKey(jti))
 }
@@ -21,6 +21,16 @@ func CheckPassword(password, hashedPassword string) bool {
 	return err == nil
 }
 
+// dummyHash 与 HashPassword 相同 cost 的固定哈希，用于 CheckPasswordDummy
+const dummyHash = "$2a$10$4UActU6zuJQkHAA2b95etO9R8rLiCuaTnIDzCnotGOONFW3naANO2"
+
+// CheckPasswordDummy 用户不存在或没有设置密码时代替 CheckPassword 调用：耗时与真实的校验相同，
+// 结果总是 false，避免通过响应时间判断用户名是否存在
+func CheckPasswordDummy(password string) bool {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+	return false
+}
+
 // HashPasswordWithCost 使用自定义cost加密密码
 func HashPasswordWithCost(password string, cost int) (string, error) {
 	// cost建议范围：10-15
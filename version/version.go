@@ -0,0 +1,43 @@
+package version
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// 构建信息，发布时通过 -ldflags 注入，例如：
+// go build -ldflags "-X blog/version.Version=v1.2.0 -X blog/version.Commit=abc123 -X blog/version.BuildTime=2025-01-01T00:00:00Z"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// StartTime 进程启动时间
+var StartTime = time.Now()
+
+func init() {
+	// 未注入提交号时，尝试从 Go 自带的构建信息中读取
+	if Commit != "" {
+		return
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			Commit = s.Value
+		case "vcs.time":
+			if BuildTime == "" {
+				BuildTime = s.Value
+			}
+		}
+	}
+}
+
+// Uptime 进程已运行时长
+func Uptime() time.Duration {
+	return time.Since(StartTime)
+}